@@ -0,0 +1,15 @@
+package database
+
+import "context"
+
+// Checkpointer is implemented by drivers that can save the state of the
+// database and return to it later, so a test can seed once and run several
+// branches from the same state.
+type Checkpointer interface {
+	// Checkpoint saves the current state of the database and returns an
+	// identifier for it.
+	Checkpoint(ctx context.Context) (string, error)
+	// Restore returns the database to the state saved by the checkpoint id.
+	// A checkpoint can be restored any number of times.
+	Restore(ctx context.Context, id string) error
+}
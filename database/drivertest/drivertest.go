@@ -0,0 +1,216 @@
+// Package drivertest checks that a database.Driver honours the contracts the
+// testine package relies on.
+package drivertest
+
+import (
+	"sort"
+	"testing"
+
+	"github.com/calumari/jwalk"
+	"github.com/calumari/testequals"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/calumari/poutine/database"
+	"github.com/calumari/poutine/exp"
+)
+
+// SQLSchema creates the tables seeded by RunConformance. Drivers that do not
+// create tables on demand should execute it (or an equivalent) in their
+// Factory.
+const SQLSchema = `
+CREATE TABLE owners (id TEXT PRIMARY KEY, name TEXT NOT NULL);
+CREATE TABLE pets (id TEXT PRIMARY KEY, name TEXT NOT NULL, age INTEGER);
+`
+
+// Factory returns a new driver backed by an empty database. It is called once
+// per subtest and should register any cleanup it needs on t.
+type Factory func(t *testing.T) database.Driver
+
+// RunConformance runs the conformance suite against drivers built by factory.
+func RunConformance(t *testing.T, factory Factory) {
+	t.Helper()
+
+	t.Run("snapshot of empty database has no documents", func(t *testing.T) {
+		d := factory(t)
+		got, err := d.Snapshot(t.Context())
+		require.NoError(t, err)
+		assertNoDocuments(t, got)
+	})
+
+	t.Run("seed returns root unchanged", func(t *testing.T) {
+		d := factory(t)
+		root := fixture()
+		seeded, err := d.Seed(t.Context(), root)
+		require.NoError(t, err)
+		assert.Equal(t, fixture(), seeded)
+	})
+
+	t.Run("snapshot matches seeded documents", func(t *testing.T) {
+		d := factory(t)
+		seeded, err := d.Seed(t.Context(), fixture())
+		require.NoError(t, err)
+		got, err := d.Snapshot(t.Context())
+		require.NoError(t, err)
+		assertShape(t, got)
+		assert.NoError(t, testequals.New(testequals.WithCollectAll()).Test(seeded, got))
+	})
+
+	t.Run("snapshot keys are sorted and unique", func(t *testing.T) {
+		d := factory(t)
+		// seed in reverse order to catch drivers echoing fixture order
+		root := jwalk.Document{
+			{Key: "pets", Value: jwalk.Array{pet("p1", "Luna", 3)}},
+			{Key: "owners", Value: jwalk.Array{owner("o1", "Alice")}},
+		}
+		_, err := d.Seed(t.Context(), root)
+		require.NoError(t, err)
+		got, err := d.Snapshot(t.Context())
+		require.NoError(t, err)
+		keys := make([]string, 0, len(got))
+		for _, e := range got {
+			keys = append(keys, e.Key)
+		}
+		assert.True(t, sort.StringsAreSorted(keys), "snapshot keys not sorted: %v", keys)
+		for i := 1; i < len(keys); i++ {
+			assert.NotEqual(t, keys[i-1], keys[i], "duplicate snapshot key %q", keys[i])
+		}
+	})
+
+	t.Run("seed twice appends documents", func(t *testing.T) {
+		d := factory(t)
+		_, err := d.Seed(t.Context(), jwalk.Document{{Key: "pets", Value: jwalk.Array{pet("p1", "Luna", 3)}}})
+		require.NoError(t, err)
+		_, err = d.Seed(t.Context(), jwalk.Document{{Key: "pets", Value: jwalk.Array{pet("p2", "Max", 5)}}})
+		require.NoError(t, err)
+		got, err := d.Snapshot(t.Context())
+		require.NoError(t, err)
+		want := jwalk.Document{{Key: "pets", Value: jwalk.Array{pet("p1", "Luna", 3), pet("p2", "Max", 5)}}}
+		assert.NoError(t, testequals.New().Test(want, got))
+	})
+
+	t.Run("empty collection seeds without documents", func(t *testing.T) {
+		d := factory(t)
+		_, err := d.Seed(t.Context(), jwalk.Document{{Key: "pets", Value: jwalk.Array{}}})
+		require.NoError(t, err)
+		got, err := d.Snapshot(t.Context())
+		require.NoError(t, err)
+		// drivers may either omit the collection or report it as empty
+		assertNoDocuments(t, got)
+	})
+
+	t.Run("empty root seeds nothing", func(t *testing.T) {
+		d := factory(t)
+		_, err := d.Seed(t.Context(), jwalk.Document{})
+		require.NoError(t, err)
+		got, err := d.Snapshot(t.Context())
+		require.NoError(t, err)
+		assertNoDocuments(t, got)
+	})
+
+	t.Run("invalid root returns error", func(t *testing.T) {
+		d := factory(t)
+		_, err := d.Seed(t.Context(), jwalk.Document{{Key: "pets", Value: "not an array"}})
+		assert.Error(t, err)
+		_, err = d.Seed(t.Context(), jwalk.Document{{Key: "pets", Value: jwalk.Array{"not a document"}}})
+		assert.Error(t, err)
+	})
+
+	t.Run("wildcard patterns round trip", func(t *testing.T) {
+		d := factory(t)
+		root := jwalk.Document{
+			{Key: "pets", Value: jwalk.Array{
+				jwalk.Document{
+					{Key: "id", Value: exp.Any("generated")},
+					{Key: "name", Value: exp.Value("Luna")},
+					{Key: "age", Value: 3},
+				},
+			}},
+		}
+		seeded, err := d.Seed(t.Context(), root)
+		require.NoError(t, err)
+		got, err := d.Snapshot(t.Context())
+		require.NoError(t, err)
+		tester := testequals.New(testequals.WithCollectAll())
+		assert.NoError(t, tester.Test(seeded, got))
+
+		// the wildcard placeholder is what gets stored
+		want := jwalk.Document{{Key: "pets", Value: jwalk.Array{pet("generated", "Luna", 3)}}}
+		assert.NoError(t, tester.Test(want, got))
+
+		// explicit patterns still compare by value
+		wrong := jwalk.Document{{Key: "pets", Value: jwalk.Array{
+			jwalk.Document{{Key: "name", Value: exp.Value("Max")}},
+		}}}
+		assert.Error(t, tester.Test(wrong, got))
+	})
+
+	t.Run("teardown removes documents", func(t *testing.T) {
+		d := factory(t)
+		_, err := d.Seed(t.Context(), fixture())
+		require.NoError(t, err)
+		require.NoError(t, d.Teardown(t.Context()))
+		got, err := d.Snapshot(t.Context())
+		require.NoError(t, err)
+		assertNoDocuments(t, got)
+	})
+
+	t.Run("teardown is idempotent", func(t *testing.T) {
+		d := factory(t)
+		require.NoError(t, d.Teardown(t.Context()))
+		require.NoError(t, d.Teardown(t.Context()))
+	})
+
+	t.Run("seed after teardown succeeds", func(t *testing.T) {
+		d := factory(t)
+		_, err := d.Seed(t.Context(), fixture())
+		require.NoError(t, err)
+		require.NoError(t, d.Teardown(t.Context()))
+		seeded, err := d.Seed(t.Context(), fixture())
+		require.NoError(t, err)
+		got, err := d.Snapshot(t.Context())
+		require.NoError(t, err)
+		assert.NoError(t, testequals.New(testequals.WithCollectAll()).Test(seeded, got))
+	})
+}
+
+func fixture() jwalk.Document {
+	return jwalk.Document{
+		{Key: "owners", Value: jwalk.Array{owner("o1", "Alice"), owner("o2", "Bob")}},
+		{Key: "pets", Value: jwalk.Array{pet("p1", "Luna", 3), pet("p2", "Max", 5)}},
+	}
+}
+
+func owner(id, name string) jwalk.Document {
+	return jwalk.Document{{Key: "id", Value: id}, {Key: "name", Value: name}}
+}
+
+func pet(id, name string, age int) jwalk.Document {
+	return jwalk.Document{{Key: "id", Value: id}, {Key: "name", Value: name}, {Key: "age", Value: age}}
+}
+
+// assertShape checks the snapshot is a document of arrays of documents.
+func assertShape(t *testing.T, snap jwalk.Document) {
+	t.Helper()
+	for _, e := range snap {
+		arr, ok := e.Value.(jwalk.Array)
+		if !assert.True(t, ok, "collection %q: expected jwalk.Array, got %T", e.Key, e.Value) {
+			continue
+		}
+		for i, el := range arr {
+			_, ok := el.(jwalk.Document)
+			assert.True(t, ok, "collection %q index %d: expected jwalk.Document, got %T", e.Key, i, el)
+		}
+	}
+}
+
+// assertNoDocuments checks every collection in the snapshot is empty.
+func assertNoDocuments(t *testing.T, snap jwalk.Document) {
+	t.Helper()
+	assertShape(t, snap)
+	for _, e := range snap {
+		if arr, ok := e.Value.(jwalk.Array); ok {
+			assert.Empty(t, arr, "collection %q: expected no documents", e.Key)
+		}
+	}
+}
@@ -0,0 +1,18 @@
+package database
+
+import "context"
+
+// Factory creates drivers bound to freshly created databases, giving each
+// test its own isolated database. Tearing the driver down must remove the
+// database again.
+type Factory[D Driver] interface {
+	// Create returns a driver for a new, empty database called name.
+	Create(ctx context.Context, name string) (D, error)
+}
+
+// FactoryFunc adapts a function to a Factory.
+type FactoryFunc[D Driver] func(ctx context.Context, name string) (D, error)
+
+func (f FactoryFunc[D]) Create(ctx context.Context, name string) (D, error) {
+	return f(ctx, name)
+}
@@ -0,0 +1,131 @@
+package sqldb
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+
+	"github.com/calumari/jwalk"
+	"github.com/go-json-experiment/json"
+	"github.com/go-json-experiment/json/jsontext"
+)
+
+type unwrappable interface {
+	UnwrapValue() any
+}
+
+type table struct {
+	name string
+	rows []jwalk.Document
+}
+
+// toTables splits a fixture into its tables. Tables keep their fixture order
+// so that rows referenced by foreign keys can be seeded first.
+func toTables(root jwalk.Document) ([]table, error) {
+	tables := make([]table, 0, len(root))
+	for _, field := range root {
+		array, ok := field.Value.(jwalk.Array)
+		if !ok {
+			return nil, fmt.Errorf("toTables: table %q expects jwalk.Array, got %T", field.Key, field.Value)
+		}
+
+		rows := make([]jwalk.Document, 0, len(array))
+		for i, element := range array {
+			doc, ok := element.(jwalk.Document)
+			if !ok {
+				return nil, fmt.Errorf("toTables: table %q index %d expects jwalk.Document, got %T", field.Key, i, element)
+			}
+			rows = append(rows, doc)
+		}
+		tables = append(tables, table{name: field.Key, rows: rows})
+	}
+	return tables, nil
+}
+
+// insertStatement builds the INSERT of a single row with the placeholders of
+// dialect. An empty row inserts the column defaults.
+func insertStatement(dialect Dialect, name string, row jwalk.Document) (string, []any, error) {
+	if len(row) == 0 {
+		return "INSERT INTO " + dialect.Table(name) + " DEFAULT VALUES", nil, nil
+	}
+	cols := make([]string, 0, len(row))
+	params := make([]string, 0, len(row))
+	args := make([]any, 0, len(row))
+	for i, f := range row {
+		cols = append(cols, QuoteIdent(f.Key))
+		params = append(params, dialect.Placeholder(i))
+		arg, err := toSQLValue(f.Value)
+		if err != nil {
+			return "", nil, fmt.Errorf("column %q: %w", f.Key, err)
+		}
+		args = append(args, arg)
+	}
+	query := "INSERT INTO " + dialect.Table(name) +
+		" (" + strings.Join(cols, ", ") + ") VALUES (" + strings.Join(params, ", ") + ")"
+	return query, args, nil
+}
+
+// toSQLValue returns the query argument for a field: patterns are unwrapped,
+// and nested documents and arrays are passed as JSON text for JSON columns.
+func toSQLValue(v any) (any, error) {
+	switch val := v.(type) {
+	case jwalk.Document, jwalk.Array:
+		b, err := marshalJSON(val)
+		if err != nil {
+			return nil, fmt.Errorf("encode json: %w", err)
+		}
+		return string(b), nil
+	case unwrappable:
+		return val.UnwrapValue(), nil
+	default:
+		return v, nil
+	}
+}
+
+// marshalJSON encodes a jwalk value as JSON, preserving document key order.
+func marshalJSON(v any) ([]byte, error) {
+	var buf bytes.Buffer
+	enc := jsontext.NewEncoder(&buf)
+	if err := encodeJSON(enc, v); err != nil {
+		return nil, err
+	}
+	return bytes.TrimSpace(buf.Bytes()), nil
+}
+
+func encodeJSON(enc *jsontext.Encoder, v any) error {
+	switch val := v.(type) {
+	case jwalk.Document:
+		if err := enc.WriteToken(jsontext.BeginObject); err != nil {
+			return err
+		}
+		for _, e := range val {
+			if err := enc.WriteToken(jsontext.String(e.Key)); err != nil {
+				return err
+			}
+			if err := encodeJSON(enc, e.Value); err != nil {
+				return err
+			}
+		}
+		return enc.WriteToken(jsontext.EndObject)
+	case jwalk.Array:
+		if err := enc.WriteToken(jsontext.BeginArray); err != nil {
+			return err
+		}
+		for _, e := range val {
+			if err := encodeJSON(enc, e); err != nil {
+				return err
+			}
+		}
+		return enc.WriteToken(jsontext.EndArray)
+	case unwrappable:
+		return encodeJSON(enc, val.UnwrapValue())
+	default:
+		return json.MarshalEncode(enc, val)
+	}
+}
+
+// QuoteIdent quotes an identifier with double quotes, as both PostgreSQL and
+// SQLite accept.
+func QuoteIdent(s string) string {
+	return `"` + strings.ReplaceAll(s, `"`, `""`) + `"`
+}
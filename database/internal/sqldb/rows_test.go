@@ -0,0 +1,112 @@
+package sqldb
+
+import (
+	"context"
+	"strconv"
+	"testing"
+
+	"github.com/calumari/jwalk"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type unwrapValue struct {
+	value any
+}
+
+func (u unwrapValue) UnwrapValue() any {
+	return u.value
+}
+
+// testDialect numbers placeholders like PostgreSQL.
+type testDialect struct{}
+
+func (testDialect) Table(name string) string           { return QuoteIdent(name) }
+func (testDialect) Placeholder(i int) string           { return "$" + strconv.Itoa(i+1) }
+func (testDialect) Value(_ string, v any) (any, error) { return v, nil }
+func (testDialect) OrderBy(context.Context, Querier, string) ([]string, error) {
+	return nil, nil
+}
+
+func Test_toTables(t *testing.T) {
+	t.Run("valid jwalk document with tables returns tables in order", func(t *testing.T) {
+		root := jwalk.Document{
+			{Key: "users", Value: jwalk.Array{
+				jwalk.Document{{Key: "name", Value: "Alice"}},
+				jwalk.Document{{Key: "name", Value: "Bob"}},
+			}},
+			{Key: "posts", Value: jwalk.Array{
+				jwalk.Document{{Key: "title", Value: "Hello"}},
+			}},
+		}
+		got, err := toTables(root)
+		require.NoError(t, err)
+		require.Len(t, got, 2)
+		assert.Equal(t, "users", got[0].name)
+		assert.Len(t, got[0].rows, 2)
+		assert.Equal(t, "posts", got[1].name)
+		assert.Len(t, got[1].rows, 1)
+	})
+
+	t.Run("non-array value for table returns error", func(t *testing.T) {
+		_, err := toTables(jwalk.Document{{Key: "users", Value: "not an array"}})
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "expects jwalk.Array")
+	})
+
+	t.Run("array with non-document value returns error", func(t *testing.T) {
+		_, err := toTables(jwalk.Document{{Key: "users", Value: jwalk.Array{"not a document"}}})
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "expects jwalk.Document")
+	})
+}
+
+func Test_insertStatement(t *testing.T) {
+	t.Run("row builds parameterised insert", func(t *testing.T) {
+		row := jwalk.Document{{Key: "id", Value: 1}, {Key: "name", Value: "Alice"}}
+		query, args, err := insertStatement(testDialect{}, "users", row)
+		require.NoError(t, err)
+		assert.Equal(t, `INSERT INTO "users" ("id", "name") VALUES ($1, $2)`, query)
+		assert.Equal(t, []any{1, "Alice"}, args)
+	})
+
+	t.Run("empty row uses default values", func(t *testing.T) {
+		query, args, err := insertStatement(testDialect{}, "users", jwalk.Document{})
+		require.NoError(t, err)
+		assert.Equal(t, `INSERT INTO "users" DEFAULT VALUES`, query)
+		assert.Empty(t, args)
+	})
+
+	t.Run("identifiers are quoted", func(t *testing.T) {
+		row := jwalk.Document{{Key: `we"ird`, Value: 1}}
+		query, _, err := insertStatement(testDialect{}, "t", row)
+		require.NoError(t, err)
+		assert.Equal(t, `INSERT INTO "t" ("we""ird") VALUES ($1)`, query)
+	})
+}
+
+func Test_toSQLValue(t *testing.T) {
+	t.Run("jwalk document encodes as json text", func(t *testing.T) {
+		got, err := toSQLValue(jwalk.Document{{Key: "b", Value: 1.0}, {Key: "a", Value: "x"}})
+		require.NoError(t, err)
+		assert.Equal(t, `{"b":1,"a":"x"}`, got)
+	})
+
+	t.Run("jwalk array encodes as json text", func(t *testing.T) {
+		got, err := toSQLValue(jwalk.Array{"go", jwalk.Document{{Key: "n", Value: unwrapValue{value: 2}}}})
+		require.NoError(t, err)
+		assert.Equal(t, `["go",{"n":2}]`, got)
+	})
+
+	t.Run("unwraps value implementing UnwrapValue", func(t *testing.T) {
+		got, err := toSQLValue(unwrapValue{value: 42})
+		require.NoError(t, err)
+		assert.Equal(t, 42, got)
+	})
+
+	t.Run("primitive value returns itself", func(t *testing.T) {
+		got, err := toSQLValue("just a string")
+		require.NoError(t, err)
+		assert.Equal(t, "just a string", got)
+	})
+}
@@ -0,0 +1,109 @@
+package sqldb
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"sort"
+
+	"github.com/calumari/jwalk"
+	"github.com/go-json-experiment/json"
+)
+
+// readRows reads every row of rows into a document keyed by column name,
+// converting each value with dialect.
+func readRows(rows *sql.Rows, dialect Dialect) (jwalk.Array, error) {
+	cols, err := rows.ColumnTypes()
+	if err != nil {
+		return nil, fmt.Errorf("column types: %w", err)
+	}
+
+	arr := jwalk.Array{}
+	for rows.Next() {
+		values := make([]any, len(cols))
+		ptrs := make([]any, len(cols))
+		for i := range values {
+			ptrs[i] = &values[i]
+		}
+		if err := rows.Scan(ptrs...); err != nil {
+			return nil, fmt.Errorf("scan row: %w", err)
+		}
+		doc := make(jwalk.Document, 0, len(cols))
+		for i, col := range cols {
+			v, err := dialect.Value(col.DatabaseTypeName(), values[i])
+			if err != nil {
+				return nil, fmt.Errorf("column %q: %w", col.Name(), err)
+			}
+			doc = append(doc, jwalk.Entry{Key: col.Name(), Value: v})
+		}
+		arr = append(arr, doc)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return arr, nil
+}
+
+// DecodeJSON decodes the text or bytes of a JSON column into jwalk values.
+// Object keys are sorted, since neither jsonb nor the decoder keeps their
+// order. Other values are returned unchanged.
+func DecodeJSON(v any) (any, error) {
+	var data []byte
+	switch val := v.(type) {
+	case string:
+		data = []byte(val)
+	case []byte:
+		data = val
+	default:
+		return v, nil
+	}
+	var raw any
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return nil, fmt.Errorf("decode json: %w", err)
+	}
+	return toJSONValue(raw), nil
+}
+
+func toJSONValue(v any) any {
+	switch val := v.(type) {
+	case map[string]any:
+		keys := make([]string, 0, len(val))
+		for k := range val {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		doc := make(jwalk.Document, 0, len(val))
+		for _, k := range keys {
+			doc = append(doc, jwalk.Entry{Key: k, Value: toJSONValue(val[k])})
+		}
+		return doc
+	case []any:
+		arr := make(jwalk.Array, 0, len(val))
+		for _, e := range val {
+			arr = append(arr, toJSONValue(e))
+		}
+		return arr
+	default:
+		return v
+	}
+}
+
+// Columns runs query and returns the quoted column names in its first column,
+// as dialects look up the primary key of a table for OrderBy.
+func Columns(ctx context.Context, q Querier, query string, args ...any) ([]string, error) {
+	rows, err := q.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var cols []string
+	for rows.Next() {
+		var name string
+		if err := rows.Scan(&name); err != nil {
+			return nil, err
+		}
+		cols = append(cols, QuoteIdent(name))
+	}
+	return cols, rows.Err()
+}
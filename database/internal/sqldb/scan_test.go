@@ -0,0 +1,38 @@
+package sqldb
+
+import (
+	"testing"
+
+	"github.com/calumari/jwalk"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDecodeJSON(t *testing.T) {
+	t.Run("object returns jwalk document with sorted keys", func(t *testing.T) {
+		got, err := DecodeJSON(`{"b":[1,{"c":true}],"a":"x"}`)
+		require.NoError(t, err)
+		want := jwalk.Document{
+			{Key: "a", Value: "x"},
+			{Key: "b", Value: jwalk.Array{1.0, jwalk.Document{{Key: "c", Value: true}}}},
+		}
+		assert.Equal(t, want, got)
+	})
+
+	t.Run("bytes are decoded", func(t *testing.T) {
+		got, err := DecodeJSON([]byte(`[1]`))
+		require.NoError(t, err)
+		assert.Equal(t, jwalk.Array{1.0}, got)
+	})
+
+	t.Run("invalid json returns error", func(t *testing.T) {
+		_, err := DecodeJSON("{")
+		assert.Error(t, err)
+	})
+
+	t.Run("other value returns itself", func(t *testing.T) {
+		got, err := DecodeJSON(nil)
+		require.NoError(t, err)
+		assert.Nil(t, got)
+	})
+}
@@ -0,0 +1,159 @@
+// Package sqldb implements the parts of the SQL drivers that do not depend on
+// the database: seeding fixture documents as rows, reading tables back into
+// documents, and binding transactions to contexts. Each driver supplies a
+// Dialect for everything else.
+package sqldb
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/calumari/jwalk"
+)
+
+// Dialect adapts statements and scanned values to a database.
+type Dialect interface {
+	// Table returns the quoted, and if needed qualified, name of a table.
+	Table(name string) string
+	// Placeholder returns the query parameter for the argument at index i.
+	Placeholder(i int) string
+	// Value converts a scanned column value of the given database type into
+	// the value fixtures use.
+	Value(typeName string, v any) (any, error)
+	// OrderBy returns the quoted expressions that order the rows of a table:
+	// its primary key, or a physical row identifier when it has none.
+	OrderBy(ctx context.Context, q Querier, table string) ([]string, error)
+}
+
+// DB seeds and reads the tables of a database through its Dialect.
+type DB struct {
+	DB      *sql.DB
+	Dialect Dialect
+}
+
+// Querier is implemented by *sql.DB and *sql.Tx.
+type Querier interface {
+	QueryContext(ctx context.Context, query string, args ...any) (*sql.Rows, error)
+}
+
+// Querier returns the transaction bound to ctx, or the database.
+func (db DB) Querier(ctx context.Context) Querier {
+	if tx, ok := TxFromContext(ctx); ok {
+		return tx
+	}
+	return db.DB
+}
+
+// Seed inserts the rows of root, one table per top-level field, in fixture
+// order. Rows are inserted in the transaction bound to ctx, or in a
+// transaction of their own so a failing row leaves no table seeded.
+func (db DB) Seed(ctx context.Context, root jwalk.Document) error {
+	tables, err := toTables(root)
+	if err != nil {
+		return fmt.Errorf("convert jwalk to rows: %w", err)
+	}
+
+	if tx, ok := TxFromContext(ctx); ok {
+		return db.insertTables(ctx, tx, tables)
+	}
+
+	tx, err := db.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	if err := db.insertTables(ctx, tx, tables); err != nil {
+		return err
+	}
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("commit transaction: %w", err)
+	}
+	return nil
+}
+
+func (db DB) insertTables(ctx context.Context, tx *sql.Tx, tables []table) error {
+	for _, t := range tables {
+		for i, r := range t.rows {
+			query, args, err := insertStatement(db.Dialect, t.name, r)
+			if err != nil {
+				return fmt.Errorf("table %q row %d: %w", t.name, i, err)
+			}
+			if _, err := tx.ExecContext(ctx, query, args...); err != nil {
+				return fmt.Errorf("insert into table %q row %d: %w", t.name, i, err)
+			}
+		}
+	}
+	return nil
+}
+
+// Snapshot reads every row of the named tables into a document with one
+// array per table. Rows are ordered by Dialect.OrderBy, as arrays are compared
+// element by element and the database may return rows in any order.
+func (db DB) Snapshot(ctx context.Context, names []string) (jwalk.Document, error) {
+	actual := make(jwalk.Document, 0, len(names))
+	for _, name := range names {
+		arr, err := db.readTable(ctx, name)
+		if err != nil {
+			return nil, err
+		}
+		actual = append(actual, jwalk.Entry{Key: name, Value: arr})
+	}
+	return actual, nil
+}
+
+func (db DB) readTable(ctx context.Context, name string) (jwalk.Array, error) {
+	q := db.Querier(ctx)
+	order, err := db.Dialect.OrderBy(ctx, q, name)
+	if err != nil {
+		return nil, fmt.Errorf("order of table %q: %w", name, err)
+	}
+	query := "SELECT * FROM " + db.Dialect.Table(name)
+	if len(order) > 0 {
+		query += " ORDER BY " + strings.Join(order, ", ")
+	}
+	rows, err := q.QueryContext(ctx, query)
+	if err != nil {
+		return nil, fmt.Errorf("select from table %q: %w", name, err)
+	}
+	defer rows.Close()
+
+	arr, err := readRows(rows, db.Dialect)
+	if err != nil {
+		return nil, fmt.Errorf("read rows from table %q: %w", name, err)
+	}
+	return arr, nil
+}
+
+type txKey struct{}
+
+// Begin starts a transaction and returns a context bound to it.
+func (db DB) Begin(ctx context.Context) (context.Context, error) {
+	tx, err := db.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("begin transaction: %w", err)
+	}
+	return context.WithValue(ctx, txKey{}, tx), nil
+}
+
+// Rollback rolls back the transaction bound to ctx. Rolling back a finished
+// transaction is not an error.
+func Rollback(ctx context.Context) error {
+	tx, ok := TxFromContext(ctx)
+	if !ok {
+		return errors.New("context is not bound to a transaction")
+	}
+	if err := tx.Rollback(); err != nil && !errors.Is(err, sql.ErrTxDone) {
+		return fmt.Errorf("rollback transaction: %w", err)
+	}
+	return nil
+}
+
+// TxFromContext returns the transaction bound to ctx by Begin.
+func TxFromContext(ctx context.Context) (*sql.Tx, bool) {
+	tx, ok := ctx.Value(txKey{}).(*sql.Tx)
+	return tx, ok
+}
@@ -0,0 +1,190 @@
+// Package memory provides an in-memory database.Driver. It needs no database
+// server, which makes it useful for unit testing code built on testine, and it
+// doubles as a reference implementation for custom drivers.
+package memory
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strconv"
+	"sync"
+
+	"github.com/calumari/jwalk"
+
+	"github.com/calumari/poutine/database"
+)
+
+type unwrappable interface {
+	UnwrapValue() any
+}
+
+// Driver stores collections as jwalk values. It is safe for concurrent use.
+type Driver struct {
+	mu          sync.RWMutex
+	collections map[string]jwalk.Array
+	checkpoints []map[string]jwalk.Array
+}
+
+var (
+	_ database.Driver       = (*Driver)(nil)
+	_ database.Checkpointer = (*Driver)(nil)
+)
+
+func NewDriver() *Driver {
+	return &Driver{
+		collections: make(map[string]jwalk.Array),
+	}
+}
+
+// Seed appends the documents of each top-level array to the collection of the
+// same name. Patterns are replaced by their values, as a real database would
+// store them. Empty arrays do not create a collection.
+func (d *Driver) Seed(_ context.Context, root jwalk.Document) (jwalk.Document, error) {
+	cols := make(map[string]jwalk.Array, len(root))
+	for _, topField := range root {
+		array, ok := topField.Value.(jwalk.Array)
+		if !ok {
+			return nil, fmt.Errorf("seed: collection %q expects jwalk.Array, got %T", topField.Key, topField.Value)
+		}
+		for i, element := range array {
+			doc, ok := element.(jwalk.Document)
+			if !ok {
+				return nil, fmt.Errorf("seed: collection %q index %d expects jwalk.Document, got %T", topField.Key, i, element)
+			}
+			cols[topField.Key] = append(cols[topField.Key], cloneValue(doc))
+		}
+	}
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	for name, docs := range cols {
+		d.collections[name] = append(d.collections[name], docs...)
+	}
+	return root, nil
+}
+
+// Snapshot returns a copy of every collection, ordered by name.
+func (d *Driver) Snapshot(_ context.Context) (jwalk.Document, error) {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+
+	actual := make(jwalk.Document, 0, len(d.collections))
+	for name, docs := range d.collections {
+		actual = append(actual, jwalk.Entry{Key: name, Value: cloneValue(docs)})
+	}
+	sort.Slice(actual, func(i, j int) bool {
+		return actual[i].Key < actual[j].Key
+	})
+	return actual, nil
+}
+
+// Teardown removes every collection and checkpoint.
+func (d *Driver) Teardown(_ context.Context) error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	clear(d.collections)
+	d.checkpoints = nil
+	return nil
+}
+
+// Checkpoint implements database.Checkpointer by copying every collection.
+func (d *Driver) Checkpoint(_ context.Context) (string, error) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.checkpoints = append(d.checkpoints, cloneCollections(d.collections))
+	return strconv.Itoa(len(d.checkpoints)), nil
+}
+
+// Restore implements database.Checkpointer, replacing every collection with
+// its copy saved by the checkpoint id.
+func (d *Driver) Restore(_ context.Context, id string) error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	n, err := strconv.Atoi(id)
+	if err != nil || n < 1 || n > len(d.checkpoints) {
+		return fmt.Errorf("unknown checkpoint %q", id)
+	}
+	d.collections = cloneCollections(d.checkpoints[n-1])
+	return nil
+}
+
+// Insert appends documents to a collection, creating it if needed. It plays the
+// role of code under test writing to the database.
+func (d *Driver) Insert(name string, docs ...jwalk.Document) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	col := d.collections[name]
+	if col == nil {
+		col = jwalk.Array{}
+	}
+	for _, doc := range docs {
+		col = append(col, cloneValue(doc))
+	}
+	d.collections[name] = col
+}
+
+// Mutate replaces a collection with the result of fn, which receives a copy of
+// the current documents (nil if the collection does not exist).
+func (d *Driver) Mutate(name string, fn func(docs jwalk.Array) jwalk.Array) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	var current jwalk.Array
+	if col, ok := d.collections[name]; ok {
+		current = cloneValue(col).(jwalk.Array)
+	}
+	next := fn(current)
+	if next == nil {
+		next = jwalk.Array{}
+	}
+	d.collections[name] = cloneValue(next).(jwalk.Array)
+}
+
+// Drop removes a collection.
+func (d *Driver) Drop(name string) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	delete(d.collections, name)
+}
+
+// Collection returns a copy of a collection's documents and whether it exists.
+func (d *Driver) Collection(name string) (jwalk.Array, bool) {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+	col, ok := d.collections[name]
+	if !ok {
+		return nil, false
+	}
+	return cloneValue(col).(jwalk.Array), true
+}
+
+func cloneCollections(cols map[string]jwalk.Array) map[string]jwalk.Array {
+	out := make(map[string]jwalk.Array, len(cols))
+	for name, docs := range cols {
+		out[name] = cloneValue(docs).(jwalk.Array)
+	}
+	return out
+}
+
+// cloneValue deep copies documents and arrays and unwraps patterns so stored
+// state never aliases caller values.
+func cloneValue(v any) any {
+	switch val := v.(type) {
+	case jwalk.Document:
+		doc := make(jwalk.Document, 0, len(val))
+		for _, e := range val {
+			doc = append(doc, jwalk.Entry{Key: e.Key, Value: cloneValue(e.Value)})
+		}
+		return doc
+	case jwalk.Array:
+		arr := make(jwalk.Array, 0, len(val))
+		for _, e := range val {
+			arr = append(arr, cloneValue(e))
+		}
+		return arr
+	case unwrappable:
+		return cloneValue(val.UnwrapValue())
+	default:
+		return v
+	}
+}
@@ -0,0 +1,160 @@
+package memory
+
+import (
+	"testing"
+
+	"github.com/calumari/jwalk"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/calumari/poutine/database"
+	"github.com/calumari/poutine/database/drivertest"
+	"github.com/calumari/poutine/exp"
+)
+
+func TestDriver_Seed(t *testing.T) {
+	t.Run("invalid document returns error", func(t *testing.T) {
+		d := NewDriver()
+		_, err := d.Seed(t.Context(), jwalk.Document{{Key: "users", Value: "not an array"}})
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "expects jwalk.Array")
+	})
+
+	t.Run("non-document element returns error", func(t *testing.T) {
+		d := NewDriver()
+		_, err := d.Seed(t.Context(), jwalk.Document{{Key: "users", Value: jwalk.Array{1}}})
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "expects jwalk.Document")
+	})
+
+	t.Run("inserts documents and unwraps patterns", func(t *testing.T) {
+		d := NewDriver()
+		root := jwalk.Document{
+			{Key: "users", Value: jwalk.Array{
+				jwalk.Document{{Key: "_id", Value: exp.Value("u1")}, {Key: "name", Value: "Alice"}},
+			}},
+		}
+		seeded, err := d.Seed(t.Context(), root)
+		require.NoError(t, err)
+		assert.Equal(t, root, seeded)
+
+		got, ok := d.Collection("users")
+		require.True(t, ok)
+		assert.Equal(t, jwalk.Array{jwalk.Document{{Key: "_id", Value: "u1"}, {Key: "name", Value: "Alice"}}}, got)
+	})
+
+	t.Run("empty array creates no collection", func(t *testing.T) {
+		d := NewDriver()
+		_, err := d.Seed(t.Context(), jwalk.Document{{Key: "users", Value: jwalk.Array{}}})
+		require.NoError(t, err)
+		_, ok := d.Collection("users")
+		assert.False(t, ok)
+	})
+
+	t.Run("seeded state does not alias fixture", func(t *testing.T) {
+		d := NewDriver()
+		doc := jwalk.Document{{Key: "name", Value: "Alice"}}
+		_, err := d.Seed(t.Context(), jwalk.Document{{Key: "users", Value: jwalk.Array{doc}}})
+		require.NoError(t, err)
+		doc[0].Value = "Mallory"
+		got, _ := d.Collection("users")
+		assert.Equal(t, "Alice", got[0].(jwalk.Document)[0].Value)
+	})
+}
+
+func TestDriver_Snapshot(t *testing.T) {
+	t.Run("returns collections ordered by name", func(t *testing.T) {
+		d := NewDriver()
+		d.Insert("users", jwalk.Document{{Key: "name", Value: "Alice"}})
+		d.Insert("posts", jwalk.Document{{Key: "title", Value: "Hello"}})
+
+		got, err := d.Snapshot(t.Context())
+		require.NoError(t, err)
+		want := jwalk.Document{
+			{Key: "posts", Value: jwalk.Array{jwalk.Document{{Key: "title", Value: "Hello"}}}},
+			{Key: "users", Value: jwalk.Array{jwalk.Document{{Key: "name", Value: "Alice"}}}},
+		}
+		assert.Equal(t, want, got)
+	})
+
+	t.Run("empty driver returns empty document", func(t *testing.T) {
+		got, err := NewDriver().Snapshot(t.Context())
+		require.NoError(t, err)
+		assert.Empty(t, got)
+	})
+}
+
+func TestDriver_Teardown(t *testing.T) {
+	t.Run("teardown removes collections", func(t *testing.T) {
+		d := NewDriver()
+		d.Insert("users", jwalk.Document{{Key: "name", Value: "Alice"}})
+		require.NoError(t, d.Teardown(t.Context()))
+		got, err := d.Snapshot(t.Context())
+		require.NoError(t, err)
+		assert.Empty(t, got)
+	})
+}
+
+func TestDriver_Mutate(t *testing.T) {
+	t.Run("mutate replaces collection", func(t *testing.T) {
+		d := NewDriver()
+		d.Insert("users", jwalk.Document{{Key: "name", Value: "Alice"}}, jwalk.Document{{Key: "name", Value: "Bob"}})
+		d.Mutate("users", func(docs jwalk.Array) jwalk.Array {
+			return docs[1:]
+		})
+		got, _ := d.Collection("users")
+		assert.Equal(t, jwalk.Array{jwalk.Document{{Key: "name", Value: "Bob"}}}, got)
+	})
+
+	t.Run("mutate missing collection receives nil", func(t *testing.T) {
+		d := NewDriver()
+		d.Mutate("users", func(docs jwalk.Array) jwalk.Array {
+			assert.Nil(t, docs)
+			return nil
+		})
+		got, ok := d.Collection("users")
+		assert.True(t, ok)
+		assert.Empty(t, got)
+	})
+}
+
+func TestDriver_Drop(t *testing.T) {
+	t.Run("drop removes collection", func(t *testing.T) {
+		d := NewDriver()
+		d.Insert("users", jwalk.Document{{Key: "name", Value: "Alice"}})
+		d.Drop("users")
+		_, ok := d.Collection("users")
+		assert.False(t, ok)
+	})
+}
+
+func TestDriver_Checkpoint(t *testing.T) {
+	t.Run("restore returns to checkpointed state", func(t *testing.T) {
+		d := NewDriver()
+		d.Insert("users", jwalk.Document{{Key: "name", Value: "Alice"}})
+		id, err := d.Checkpoint(t.Context())
+		require.NoError(t, err)
+
+		for range 2 {
+			d.Mutate("users", func(jwalk.Array) jwalk.Array { return nil })
+			d.Insert("posts", jwalk.Document{{Key: "title", Value: "Hello"}})
+			require.NoError(t, d.Restore(t.Context(), id))
+			got, err := d.Snapshot(t.Context())
+			require.NoError(t, err)
+			assert.Equal(t, jwalk.Document{
+				{Key: "users", Value: jwalk.Array{jwalk.Document{{Key: "name", Value: "Alice"}}}},
+			}, got)
+		}
+	})
+
+	t.Run("unknown checkpoint returns error", func(t *testing.T) {
+		d := NewDriver()
+		assert.Error(t, d.Restore(t.Context(), "1"))
+	})
+}
+
+func TestConformance(t *testing.T) {
+	drivertest.RunConformance(t, func(t *testing.T) database.Driver {
+		return NewDriver()
+	})
+}
@@ -0,0 +1,184 @@
+package mongodb
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/calumari/jwalk"
+	"github.com/calumari/testequals"
+	"github.com/go-json-experiment/json"
+	"github.com/go-json-experiment/json/jsontext"
+	"go.mongodb.org/mongo-driver/v2/bson"
+
+	"github.com/calumari/poutine/exp"
+)
+
+// NewDateDirective returns a $date directive whose "seed" anchor resolves
+// through seedTime. It decodes these forms:
+//
+//	{"$date": "2024-01-02T03:04:05Z"}                  // exact bson.DateTime (RFC 3339)
+//	{"$date": {"$numberLong": "1704164645000"}}        // exact bson.DateTime (canonical)
+//	{"$date": true}                                    // any bson.DateTime
+//	{"$date": {"within": "5s"}}                        // within 5s of now
+//	{"$date": {"within": "1m", "of": "seed"}}          // within 1m of the last Seed
+//	{"$date": {"after": "seed", "before": "now"}}      // between the last Seed and now
+//
+// Anchors are "now", "seed" or an RFC 3339 time and are resolved when the
+// assertion runs, so fixtures may be loaded before seeding.
+func NewDateDirective(seedTime func() time.Time) *jwalk.Directive {
+	return jwalk.NewDirective("date", func(dec *jsontext.Decoder) (any, error) {
+		if dec.PeekKind() == '{' {
+			var payload map[string]string
+			if err := json.UnmarshalDecode(dec, &payload); err != nil {
+				return nil, fmt.Errorf("invalid $date payload: %w", err)
+			}
+			if ms, ok := payload["$numberLong"]; ok && len(payload) == 1 {
+				n, err := strconv.ParseInt(ms, 10, 64)
+				if err != nil {
+					return nil, fmt.Errorf("invalid $date $numberLong: %w", err)
+				}
+				return exp.Value(bson.DateTime(n)), nil
+			}
+			return newDateRange(payload, seedTime)
+		}
+		return unmarshalDatePattern(dec)
+	})
+}
+
+// anchor is a point in time resolved when a dateRange is tested.
+type anchor struct {
+	name string
+	at   func() (time.Time, error)
+}
+
+// dateRange matches dates relative to anchors.
+type dateRange struct {
+	within time.Duration
+	of     *anchor
+	after  *anchor
+	before *anchor
+}
+
+var _ testequals.Rule = dateRange{}
+
+func newDateRange(payload map[string]string, seedTime func() time.Time) (dateRange, error) {
+	var r dateRange
+	for key, value := range payload {
+		var err error
+		switch key {
+		case "within":
+			r.within, err = time.ParseDuration(value)
+			if err == nil && r.within < 0 {
+				err = fmt.Errorf("negative duration %q", value)
+			}
+		case "of":
+			r.of, err = parseAnchor(value, seedTime)
+		case "after":
+			r.after, err = parseAnchor(value, seedTime)
+		case "before":
+			r.before, err = parseAnchor(value, seedTime)
+		default:
+			err = fmt.Errorf("unknown key %q", key)
+		}
+		if err != nil {
+			return dateRange{}, fmt.Errorf("invalid $date %s: %w", key, err)
+		}
+	}
+	if r.of != nil && r.within == 0 {
+		return dateRange{}, fmt.Errorf("invalid $date: %q requires %q", "of", "within")
+	}
+	if r.within > 0 && r.of == nil {
+		r.of, _ = parseAnchor("now", seedTime)
+	}
+	if r.within == 0 && r.after == nil && r.before == nil {
+		return dateRange{}, fmt.Errorf("invalid $date: expected within, after or before")
+	}
+	return r, nil
+}
+
+func parseAnchor(value string, seedTime func() time.Time) (*anchor, error) {
+	switch value {
+	case "now":
+		return &anchor{name: value, at: func() (time.Time, error) { return time.Now(), nil }}, nil
+	case "seed":
+		if seedTime == nil {
+			return nil, fmt.Errorf("seed anchor requires the directive registered by Driver.RegisterTypes")
+		}
+		return &anchor{name: value, at: func() (time.Time, error) {
+			t := seedTime()
+			if t.IsZero() {
+				return time.Time{}, fmt.Errorf("no seed time recorded")
+			}
+			return t, nil
+		}}, nil
+	default:
+		t, err := time.Parse(time.RFC3339Nano, value)
+		if err != nil {
+			return nil, err
+		}
+		return &anchor{name: value, at: func() (time.Time, error) { return t, nil }}, nil
+	}
+}
+
+// UnwrapValue seeds the current time.
+func (r dateRange) UnwrapValue() any {
+	return bson.NewDateTimeFromTime(time.Now())
+}
+
+func (r dateRange) Test(_ *testequals.RuleContext, actual any) error {
+	var got time.Time
+	switch v := actual.(type) {
+	case bson.DateTime:
+		got = v.Time()
+	case time.Time:
+		got = v
+	default:
+		return fmt.Errorf("expected bson.DateTime, got %T", actual)
+	}
+	// stored dates have millisecond precision, so anchors are truncated to
+	// avoid rejecting a date written in the same millisecond
+	if r.of != nil {
+		at, err := r.of.at()
+		if err != nil {
+			return err
+		}
+		if d := got.Sub(at.Truncate(time.Millisecond)).Abs(); d > r.within {
+			return fmt.Errorf("expected date within %s of %s, got %s (off by %s)", r.within, r.of.name, got.UTC().Format(time.RFC3339Nano), d)
+		}
+	}
+	if r.after != nil {
+		at, err := r.after.at()
+		if err != nil {
+			return err
+		}
+		if got.Before(at.Truncate(time.Millisecond)) {
+			return fmt.Errorf("expected date after %s (%s), got %s", r.after.name, at.UTC().Format(time.RFC3339Nano), got.UTC().Format(time.RFC3339Nano))
+		}
+	}
+	if r.before != nil {
+		at, err := r.before.at()
+		if err != nil {
+			return err
+		}
+		if got.After(at) {
+			return fmt.Errorf("expected date before %s (%s), got %s", r.before.name, at.UTC().Format(time.RFC3339Nano), got.UTC().Format(time.RFC3339Nano))
+		}
+	}
+	return nil
+}
+
+func (r dateRange) String() string {
+	var parts []string
+	if r.of != nil {
+		parts = append(parts, fmt.Sprintf("within %s of %s", r.within, r.of.name))
+	}
+	if r.after != nil {
+		parts = append(parts, "after "+r.after.name)
+	}
+	if r.before != nil {
+		parts = append(parts, "before "+r.before.name)
+	}
+	return "date " + strings.Join(parts, " and ")
+}
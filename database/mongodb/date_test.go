@@ -0,0 +1,119 @@
+package mongodb
+
+import (
+	"testing"
+	"time"
+
+	"github.com/calumari/jwalk"
+	"github.com/calumari/testequals"
+	"github.com/go-json-experiment/json"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.mongodb.org/mongo-driver/v2/bson"
+
+	"github.com/calumari/poutine/exp"
+)
+
+func decodeDate(t *testing.T, seedTime func() time.Time, payload string) (any, error) {
+	t.Helper()
+	reg, err := jwalk.NewRegistry(jwalk.WithDirective(NewDateDirective(seedTime)))
+	require.NoError(t, err)
+	var doc jwalk.Document
+	if err := json.Unmarshal([]byte(`{"v":[{"v":`+payload+`}]}`), &doc, json.WithUnmarshalers(jwalk.Unmarshalers(reg))); err != nil {
+		return nil, err
+	}
+	return doc[0].Value.(jwalk.Array)[0].(jwalk.Document)[0].Value, nil
+}
+
+func Test_NewDateDirective(t *testing.T) {
+	now := time.Now()
+	seeded := now.Add(-time.Minute)
+	seedTime := func() time.Time { return seeded }
+	tester := testequals.New()
+
+	t.Run("rfc3339 returns exact pattern", func(t *testing.T) {
+		got, err := decodeDate(t, nil, `{"$date": "2024-01-02T03:04:05Z"}`)
+		require.NoError(t, err)
+		want := bson.NewDateTimeFromTime(time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC))
+		assert.Equal(t, exp.Value(want), got)
+	})
+
+	t.Run("true returns wildcard pattern", func(t *testing.T) {
+		got, err := decodeDate(t, nil, `{"$date": true}`)
+		require.NoError(t, err)
+		p, ok := got.(exp.Pattern[bson.DateTime])
+		require.True(t, ok)
+		assert.True(t, p.IsWildcard())
+	})
+
+	tests := []struct {
+		name    string
+		payload string
+		actual  time.Time
+		wantErr string
+	}{
+		{"within now matches", `{"within": "5s"}`, now.Add(-2 * time.Second), ""},
+		{"within now rejects", `{"within": "5s"}`, now.Add(-time.Hour), "expected date within 5s of now"},
+		{"within seed matches", `{"within": "1s", "of": "seed"}`, seeded.Add(500 * time.Millisecond), ""},
+		{"within fixed time matches", `{"within": "1h", "of": "2024-01-02T03:04:05Z"}`, time.Date(2024, 1, 2, 3, 30, 0, 0, time.UTC), ""},
+		{"after seed matches", `{"after": "seed"}`, seeded.Add(time.Second), ""},
+		{"after seed same millisecond matches", `{"after": "seed"}`, seeded.Truncate(time.Millisecond), ""},
+		{"after seed rejects", `{"after": "seed"}`, seeded.Add(-time.Second), "expected date after seed"},
+		{"between seed and now matches", `{"after": "seed", "before": "now"}`, now.Add(-time.Second), ""},
+		{"before rejects", `{"before": "2024-01-01T00:00:00Z"}`, now, "expected date before 2024-01-01T00:00:00Z"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := decodeDate(t, seedTime, `{"$date": `+tt.payload+`}`)
+			require.NoError(t, err)
+			err = tester.Test(got, bson.NewDateTimeFromTime(tt.actual))
+			if tt.wantErr == "" {
+				assert.NoError(t, err)
+			} else {
+				assert.ErrorContains(t, err, tt.wantErr)
+			}
+		})
+	}
+
+	t.Run("non date actual returns error", func(t *testing.T) {
+		got, err := decodeDate(t, seedTime, `{"$date": {"within": "5s"}}`)
+		require.NoError(t, err)
+		assert.ErrorContains(t, tester.Test(got, "2024-01-02"), "expected bson.DateTime, got string")
+	})
+
+	t.Run("unrecorded seed time returns error", func(t *testing.T) {
+		got, err := decodeDate(t, func() time.Time { return time.Time{} }, `{"$date": {"after": "seed"}}`)
+		require.NoError(t, err)
+		assert.ErrorContains(t, tester.Test(got, bson.NewDateTimeFromTime(now)), "no seed time recorded")
+	})
+
+	t.Run("seeds current time", func(t *testing.T) {
+		got, err := decodeDate(t, seedTime, `{"$date": {"within": "5s"}}`)
+		require.NoError(t, err)
+		seed, ok := got.(unwrappable).UnwrapValue().(bson.DateTime)
+		require.True(t, ok)
+		assert.WithinDuration(t, now, seed.Time(), 5*time.Second)
+	})
+
+	invalid := []struct {
+		name    string
+		payload string
+		wantErr string
+	}{
+		{"unknown key", `{"around": "5s"}`, `unknown key "around"`},
+		{"bad duration", `{"within": "soon"}`, "invalid $date within"},
+		{"of without within", `{"of": "seed"}`, `"of" requires "within"`},
+		{"empty object", `{}`, "expected within, after or before"},
+		{"seed without driver", `{"after": "seed"}`, "seed anchor requires"},
+	}
+	for _, tt := range invalid {
+		t.Run(tt.name+" returns error", func(t *testing.T) {
+			var seedFn func() time.Time
+			if tt.name != "seed without driver" {
+				seedFn = seedTime
+			}
+			_, err := decodeDate(t, seedFn, `{"$date": `+tt.payload+`}`)
+			assert.ErrorContains(t, err, tt.wantErr)
+		})
+	}
+}
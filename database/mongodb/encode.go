@@ -0,0 +1,117 @@
+package mongodb
+
+import (
+	"encoding/base64"
+	"fmt"
+	"math"
+	"strconv"
+	"time"
+
+	"github.com/go-json-experiment/json"
+	"github.com/go-json-experiment/json/jsontext"
+	"github.com/google/uuid"
+	"go.mongodb.org/mongo-driver/v2/bson"
+)
+
+// Marshalers encodes BSON values as Extended JSON directives that decode back
+// into the same values when loaded with the driver's registered directives.
+var Marshalers = json.JoinMarshalers(
+	json.MarshalToFunc(func(enc *jsontext.Encoder, v bson.ObjectID) error {
+		return writeDirective(enc, "oid", v.Hex())
+	}),
+	json.MarshalToFunc(func(enc *jsontext.Encoder, v bson.DateTime) error {
+		return writeDirective(enc, "date", v.Time().UTC().Format(time.RFC3339Nano))
+	}),
+	json.MarshalToFunc(func(enc *jsontext.Encoder, v bson.Decimal128) error {
+		return writeDirective(enc, "numberDecimal", v.String())
+	}),
+	json.MarshalToFunc(func(enc *jsontext.Encoder, v bson.Binary) error {
+		if v.Subtype == bson.TypeBinaryUUID && len(v.Data) == 16 {
+			return writeDirective(enc, "uuid", uuid.UUID(v.Data).String())
+		}
+		return writeDirective(enc, "binary", binaryPayload{
+			Base64:  base64.StdEncoding.EncodeToString(v.Data),
+			SubType: fmt.Sprintf("%02x", v.Subtype),
+		})
+	}),
+	json.MarshalToFunc(func(enc *jsontext.Encoder, v bson.Timestamp) error {
+		return writeDirective(enc, "timestamp", timestampPayload{T: v.T, I: v.I})
+	}),
+	json.MarshalToFunc(func(enc *jsontext.Encoder, v bson.Regex) error {
+		return writeDirective(enc, "regularExpression", regexPayload{Pattern: v.Pattern, Options: v.Options})
+	}),
+	json.MarshalToFunc(func(enc *jsontext.Encoder, v int32) error {
+		return writeDirective(enc, "numberInt", strconv.FormatInt(int64(v), 10))
+	}),
+	json.MarshalToFunc(func(enc *jsontext.Encoder, v int64) error {
+		return writeDirective(enc, "numberLong", strconv.FormatInt(v, 10))
+	}),
+	json.MarshalToFunc(func(enc *jsontext.Encoder, v float64) error {
+		switch {
+		case math.IsInf(v, 1):
+			return writeDirective(enc, "numberDouble", "Infinity")
+		case math.IsInf(v, -1):
+			return writeDirective(enc, "numberDouble", "-Infinity")
+		case math.IsNaN(v):
+			return writeDirective(enc, "numberDouble", "NaN")
+		default:
+			return json.SkipFunc // finite doubles stay plain numbers
+		}
+	}),
+	json.MarshalToFunc(func(enc *jsontext.Encoder, v bson.MinKey) error {
+		return writeDirective(enc, "minKey", 1)
+	}),
+	json.MarshalToFunc(func(enc *jsontext.Encoder, v bson.MaxKey) error {
+		return writeDirective(enc, "maxKey", 1)
+	}),
+	json.MarshalToFunc(func(enc *jsontext.Encoder, v bson.JavaScript) error {
+		return writeDirective(enc, "code", string(v))
+	}),
+	json.MarshalToFunc(func(enc *jsontext.Encoder, v bson.CodeWithScope) error {
+		scope, err := bson.MarshalExtJSON(v.Scope, true, false)
+		if err != nil {
+			return err
+		}
+		if err := enc.WriteToken(jsontext.BeginObject); err != nil {
+			return err
+		}
+		if err := enc.WriteToken(jsontext.String("$code")); err != nil {
+			return err
+		}
+		if err := enc.WriteToken(jsontext.String(string(v.Code))); err != nil {
+			return err
+		}
+		if err := enc.WriteToken(jsontext.String("$scope")); err != nil {
+			return err
+		}
+		if err := enc.WriteValue(scope); err != nil {
+			return err
+		}
+		return enc.WriteToken(jsontext.EndObject)
+	}),
+	json.MarshalToFunc(func(enc *jsontext.Encoder, v bson.Symbol) error {
+		return writeDirective(enc, "symbol", string(v))
+	}),
+	json.MarshalToFunc(func(enc *jsontext.Encoder, v bson.Undefined) error {
+		return writeDirective(enc, "undefined", true)
+	}),
+	json.MarshalToFunc(func(enc *jsontext.Encoder, v bson.DBPointer) error {
+		var p dbPointerPayload
+		p.Ref, p.ID.OID = v.DB, v.Pointer.Hex()
+		return writeDirective(enc, "dbPointer", p)
+	}),
+)
+
+// writeDirective writes {"$name": payload}.
+func writeDirective(enc *jsontext.Encoder, name string, payload any) error {
+	if err := enc.WriteToken(jsontext.BeginObject); err != nil {
+		return err
+	}
+	if err := enc.WriteToken(jsontext.String("$" + name)); err != nil {
+		return err
+	}
+	if err := json.MarshalEncode(enc, payload); err != nil {
+		return err
+	}
+	return enc.WriteToken(jsontext.EndObject)
+}
@@ -0,0 +1,238 @@
+package mongodb
+
+import (
+	"math"
+	"testing"
+	"time"
+
+	"github.com/calumari/jwalk"
+	"github.com/calumari/testequals"
+	"github.com/go-json-experiment/json"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.mongodb.org/mongo-driver/v2/bson"
+
+	"github.com/calumari/poutine/exp"
+)
+
+func Test_Marshalers(t *testing.T) {
+	oid, err := bson.ObjectIDFromHex("507f1f77bcf86cd799439011")
+	require.NoError(t, err)
+	dec, err := bson.ParseDecimal128("12.50")
+	require.NoError(t, err)
+	date := bson.NewDateTimeFromTime(time.Date(2024, 1, 2, 3, 4, 5, 6e6, time.UTC))
+
+	tests := []struct {
+		name  string
+		value any
+		want  string
+	}{
+		{"object id", oid, `{"$oid":"507f1f77bcf86cd799439011"}`},
+		{"date", date, `{"$date":"2024-01-02T03:04:05.006Z"}`},
+		{"decimal", dec, `{"$numberDecimal":"12.50"}`},
+		{"binary", bson.Binary{Subtype: 4, Data: []byte{1, 2, 3}}, `{"$binary":{"base64":"AQID","subType":"04"}}`},
+		{"timestamp", bson.Timestamp{T: 10, I: 2}, `{"$timestamp":{"t":10,"i":2}}`},
+		{"regex", bson.Regex{Pattern: "^a", Options: "i"}, `{"$regularExpression":{"pattern":"^a","options":"i"}}`},
+		{"uuid", bson.Binary{Subtype: 4, Data: []byte{0x2f, 0x1e, 0x2d, 0x3c, 0x4b, 0x5a, 0x49, 0x68, 0x87, 0x76, 0x65, 0x54, 0x43, 0x32, 0x21, 0x10}}, `{"$uuid":"2f1e2d3c-4b5a-4968-8776-655443322110"}`},
+		{"int32", int32(3), `{"$numberInt":"3"}`},
+		{"int64", int64(9007199254740993), `{"$numberLong":"9007199254740993"}`},
+		{"infinity", math.Inf(1), `{"$numberDouble":"Infinity"}`},
+		{"min key", bson.MinKey{}, `{"$minKey":1}`},
+		{"max key", bson.MaxKey{}, `{"$maxKey":1}`},
+		{"code", bson.JavaScript("function() {}"), `{"$code":"function() {}"}`},
+		{"code with scope", bson.CodeWithScope{Code: "f(x)", Scope: bson.D{{Key: "x", Value: int32(1)}}}, `{"$code":"f(x)","$scope":{"x":{"$numberInt":"1"}}}`},
+		{"symbol", bson.Symbol("sym"), `{"$symbol":"sym"}`},
+		{"undefined", bson.Undefined{}, `{"$undefined":true}`},
+		{"db pointer", bson.DBPointer{DB: "db.users", Pointer: oid}, `{"$dbPointer":{"$ref":"db.users","$id":{"$oid":"507f1f77bcf86cd799439011"}}}`},
+	}
+	reg, err := jwalk.NewRegistry()
+	require.NoError(t, err)
+	require.NoError(t, (&Driver{}).RegisterTypes(reg))
+
+	for _, tt := range tests {
+		t.Run(tt.name+" encodes directive and round trips", func(t *testing.T) {
+			got, err := json.Marshal(tt.value, json.WithMarshalers(Marshalers))
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, string(got))
+
+			var doc jwalk.Document
+			err = json.Unmarshal([]byte(`{"v":[{"v":`+string(got)+`}]}`), &doc, json.WithUnmarshalers(jwalk.Unmarshalers(reg)))
+			require.NoError(t, err)
+			pattern := doc[0].Value.(jwalk.Array)[0].(jwalk.Document)[0].Value
+			u, ok := pattern.(unwrappable)
+			require.True(t, ok, "got %T", pattern)
+			assert.Equal(t, tt.value, u.UnwrapValue())
+		})
+	}
+
+	t.Run("numbers round trip with their type", func(t *testing.T) {
+		values := map[string]any{"n": int32(3), "l": int64(7), "f": 1.5}
+		got, err := json.Marshal(values, json.WithMarshalers(Marshalers))
+		require.NoError(t, err)
+
+		var doc jwalk.Document
+		err = json.Unmarshal(got, &doc, json.WithUnmarshalers(jwalk.Unmarshalers(reg)))
+		require.NoError(t, err)
+		decoded := map[string]any{}
+		for _, e := range doc {
+			if u, ok := e.Value.(unwrappable); ok {
+				decoded[e.Key] = u.UnwrapValue()
+			} else {
+				decoded[e.Key] = e.Value
+			}
+		}
+		assert.Equal(t, values, decoded)
+	})
+
+	t.Run("nan encodes directive", func(t *testing.T) {
+		got, err := json.Marshal(math.NaN(), json.WithMarshalers(Marshalers))
+		require.NoError(t, err)
+		assert.Equal(t, `{"$numberDouble":"NaN"}`, string(got))
+	})
+}
+
+func Test_unmarshalWildcard(t *testing.T) {
+	reg, err := jwalk.NewRegistry()
+	require.NoError(t, err)
+	require.NoError(t, (&Driver{}).RegisterTypes(reg))
+	decode := func(t *testing.T, directive string) (any, error) {
+		t.Helper()
+		var doc jwalk.Document
+		err := json.Unmarshal([]byte(`{"v":[{"v":`+directive+`}]}`), &doc, json.WithUnmarshalers(jwalk.Unmarshalers(reg)))
+		if err != nil {
+			return nil, err
+		}
+		return doc[0].Value.(jwalk.Array)[0].(jwalk.Document)[0].Value, nil
+	}
+
+	valueTests := []struct {
+		name      string
+		directive string
+		want      any
+	}{
+		{"number long", `{"$numberLong": "9007199254740993"}`, int64(9007199254740993)},
+		{"number int", `{"$numberInt": "-42"}`, int32(-42)},
+		{"number double", `{"$numberDouble": "-Infinity"}`, math.Inf(-1)},
+		{"canonical date", `{"$date": {"$numberLong": "1704164645006"}}`, bson.DateTime(1704164645006)},
+	}
+	for _, tt := range valueTests {
+		t.Run(tt.name+" returns exact pattern", func(t *testing.T) {
+			got, err := decode(t, tt.directive)
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, got.(unwrappable).UnwrapValue())
+		})
+	}
+
+	wildcardTests := []string{"numberLong", "numberInt", "numberDouble", "numberDecimal", "uuid", "binary", "timestamp", "regularExpression", "minKey", "maxKey", "code", "symbol", "dbPointer", "oid", "date"}
+	for _, name := range wildcardTests {
+		t.Run(name+" true returns wildcard", func(t *testing.T) {
+			got, err := decode(t, `{"$`+name+`": true}`)
+			require.NoError(t, err)
+			w, ok := got.(interface{ IsWildcard() bool })
+			require.True(t, ok, "got %T", got)
+			assert.True(t, w.IsWildcard())
+		})
+	}
+
+	t.Run("reference string returns named pattern", func(t *testing.T) {
+		got, err := decode(t, `{"$oid": "@alice"}`)
+		require.NoError(t, err)
+		p, ok := got.(exp.Pattern[bson.ObjectID])
+		require.True(t, ok)
+		name, ok := p.RefName()
+		assert.True(t, ok)
+		assert.Equal(t, "alice", name)
+
+		got, err = decode(t, `{"$numberLong": "?count"}`)
+		require.NoError(t, err)
+		name, _ = got.(exp.Pattern[int64]).RefName()
+		assert.Equal(t, "count", name)
+	})
+
+	t.Run("reference prefix on non identifier type stays literal", func(t *testing.T) {
+		got, err := decode(t, `{"$symbol": "@alice"}`)
+		require.NoError(t, err)
+		assert.Equal(t, bson.Symbol("@alice"), got.(unwrappable).UnwrapValue())
+	})
+
+	t.Run("wildcard uuid matches any uuid binary", func(t *testing.T) {
+		got, err := decode(t, `{"$uuid": true}`)
+		require.NoError(t, err)
+		assert.NoError(t, testequals.New().Test(got, bson.Binary{Subtype: 4, Data: make([]byte, 16)}))
+	})
+
+	t.Run("wildcard uuid rejects other binary subtypes", func(t *testing.T) {
+		got, err := decode(t, `{"$uuid": true}`)
+		require.NoError(t, err)
+		assert.ErrorContains(t, testequals.New().Test(got, bson.Binary{Subtype: 0, Data: make([]byte, 16)}), "expected binary subtype 04, got 00")
+
+		bound := got.(exp.Bindable).Bind(exp.NewBindings())
+		assert.Error(t, testequals.New().Test(bound, bson.Binary{Subtype: 3, Data: make([]byte, 16)}))
+	})
+
+	t.Run("named uuid rejects other binary subtypes", func(t *testing.T) {
+		got, err := decode(t, `{"$uuid": "?id"}`)
+		require.NoError(t, err)
+		bound := got.(exp.Bindable).Bind(exp.NewBindings())
+		assert.Error(t, testequals.New().Test(bound, bson.Binary{Subtype: 3, Data: make([]byte, 16)}))
+	})
+
+	t.Run("wildcard timestamp seeds non zero value", func(t *testing.T) {
+		got, err := decode(t, `{"$timestamp": true}`)
+		require.NoError(t, err)
+		assert.NotZero(t, got.(unwrappable).UnwrapValue())
+	})
+
+	t.Run("true returns wildcard", func(t *testing.T) {
+		got, err := decode(t, `{"$date": true}`)
+		require.NoError(t, err)
+		p, ok := got.(exp.Pattern[bson.DateTime])
+		require.True(t, ok)
+		assert.True(t, p.IsWildcard())
+	})
+
+	t.Run("false returns error", func(t *testing.T) {
+		_, err := decode(t, `{"$timestamp": false}`)
+		assert.ErrorContains(t, err, "$timestamp bool must be true")
+	})
+
+	t.Run("out of range number returns error", func(t *testing.T) {
+		_, err := decode(t, `{"$numberInt": "3000000000"}`)
+		assert.Error(t, err)
+		_, err = decode(t, `{"$minKey": 2}`)
+		assert.ErrorContains(t, err, "$minKey must be 1")
+	})
+
+	t.Run("code scope wildcard returns error", func(t *testing.T) {
+		_, err := decode(t, `{"$code": true, "$scope": {}}`)
+		assert.ErrorContains(t, err, "cannot have a $scope")
+	})
+
+	t.Run("code with scope reads sibling member", func(t *testing.T) {
+		got, err := decode(t, `{"$code": "f(x)", "$scope": {"x": {"$numberInt": "1"}}}`)
+		require.NoError(t, err)
+		assert.Equal(t, bson.CodeWithScope{Code: "f(x)", Scope: bson.D{{Key: "x", Value: int32(1)}}}, got.(unwrappable).UnwrapValue())
+	})
+
+	t.Run("code with unknown sibling returns error", func(t *testing.T) {
+		_, err := decode(t, `{"$code": "f(x)", "$other": {}}`)
+		assert.ErrorContains(t, err, `unexpected key "$other" after $code`)
+	})
+
+	t.Run("code with extra member after scope returns error", func(t *testing.T) {
+		_, err := decode(t, `{"$code": "f(x)", "$scope": {}, "x": 1}`)
+		assert.ErrorContains(t, err, "unexpected key after $scope")
+	})
+
+	t.Run("scope before code returns error", func(t *testing.T) {
+		_, err := decode(t, `{"$scope": {}, "$code": "f(x)"}`)
+		assert.Error(t, err)
+	})
+
+	t.Run("invalid payload returns error", func(t *testing.T) {
+		_, err := decode(t, `{"$date": "yesterday"}`)
+		assert.Error(t, err)
+		_, err = decode(t, `{"$binary": {"base64": "AQID", "subType": "zz"}}`)
+		assert.ErrorContains(t, err, "invalid $binary subType")
+	})
+}
@@ -7,7 +7,6 @@ import (
 	"fmt"
 	"testing"
 
-	"github.com/google/uuid"
 	"github.com/stretchr/testify/require"
 	"github.com/stretchr/testify/suite"
 	"github.com/testcontainers/testcontainers-go"
@@ -16,7 +15,6 @@ import (
 	"go.mongodb.org/mongo-driver/v2/mongo"
 	"go.mongodb.org/mongo-driver/v2/mongo/options"
 
-	"github.com/calumari/poutine"
 	"github.com/calumari/poutine/database/mongodb"
 	"github.com/calumari/poutine/testine"
 )
@@ -25,9 +23,7 @@ type RepositorySuite struct {
 	suite.Suite
 	mongoContainer testcontainers.Container
 	client         *mongo.Client
-	repository     *Repository
-	poutine        *poutine.Poutine
-	testine        *testine.T
+	pool           *testine.Pool[*mongodb.Driver]
 }
 
 func (s *RepositorySuite) SetupSuite() {
@@ -58,48 +54,37 @@ func (s *RepositorySuite) SetupSuite() {
 	err = client.Ping(t.Context(), nil)
 	require.NoError(t, err)
 	s.client = client
+	s.pool = testine.NewPool(mongodb.NewFactory(client))
 }
 
 func (s *RepositorySuite) TearDownSuite() {
 	_ = s.mongoContainer.Terminate(context.Background())
 }
 
-func (s *RepositorySuite) SetupSubTest() {
-	t := s.T()
-
-	dbName := fmt.Sprintf("poutine_example_test_%s", uuid.NewString())
-	fmt.Println("Using database:", dbName)
-	db := s.client.Database(dbName)
-
-	s.poutine = poutine.New(mongodb.NewDriver(db))
-	pt, err := testine.New(s.poutine)
-	require.NoError(t, err)
-	s.testine = pt
-
-	s.repository = NewRepository(db)
-}
-
-func (s *RepositorySuite) TearDownTest() {
-	err := s.poutine.Teardown(context.Background())
-	require.NoError(s.T(), err)
+// setup returns the test helper and a repository sharing a database of their
+// own, which is dropped when the subtest completes.
+func (s *RepositorySuite) setup(t *testing.T) (*testine.T, *Repository) {
+	return s.pool.For(t), NewRepository(s.pool.Driver(t).Database())
 }
 
 func (s *RepositorySuite) TestCreatePet() {
 	s.Run("create pet adds pet", func() {
 		t := s.T()
-		err := s.repository.Create(t.Context(), &Pet{Name: "Luna", Type: "cat"})
+		ti, repository := s.setup(t)
+		err := repository.Create(t.Context(), &Pet{Name: "Luna", Type: "cat"})
 		require.NoError(t, err)
 
-		s.testine.Assert(t, s.testine.LoadJSON(t, "test_data/pets_after_create.json"))
+		ti.Assert(t, ti.LoadJSON(t, "test_data/pets_after_create.json"))
 	})
 }
 
 func (s *RepositorySuite) TestDeletePet() {
 	s.Run("delete non-existing pet keeps snapshot unchanged", func() {
 		t := s.T()
-		snapshot := s.testine.Seed(t, s.testine.LoadJSON(t, "test_data/pets_seed.json"))
+		ti, repository := s.setup(t)
+		snapshot := ti.Seed(t, ti.LoadJSON(t, "test_data/pets_seed.json"))
 
-		err := s.repository.Delete(t.Context(), "NonExistent")
+		err := repository.Delete(t.Context(), "NonExistent")
 		require.NoError(t, err)
 
 		snapshot.Assert(t)
@@ -107,12 +92,13 @@ func (s *RepositorySuite) TestDeletePet() {
 
 	s.Run("delete existing pet removes pet", func() {
 		t := s.T()
-		_ = s.testine.Seed(t, s.testine.LoadJSON(t, "test_data/pets_seed.json"))
+		ti, repository := s.setup(t)
+		_ = ti.Seed(t, ti.LoadJSON(t, "test_data/pets_seed.json"))
 
-		err := s.repository.Delete(t.Context(), "Max")
+		err := repository.Delete(t.Context(), "Max")
 		require.NoError(t, err)
 
-		s.testine.Assert(t, s.testine.LoadJSON(t, "test_data/pets_after_delete.json"))
+		ti.Assert(t, ti.LoadJSON(t, "test_data/pets_after_delete.json"))
 	})
 }
 
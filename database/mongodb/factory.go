@@ -0,0 +1,30 @@
+package mongodb
+
+import (
+	"context"
+
+	"go.mongodb.org/mongo-driver/v2/mongo"
+
+	"github.com/calumari/poutine/database"
+)
+
+// Factory creates a Driver per test database on a shared client, for use with
+// testine.NewPool. MongoDB creates databases lazily, so Create does not touch
+// the server; Teardown drops the database unless another teardown strategy
+// is set.
+type Factory struct {
+	client *mongo.Client
+	opts   []Option
+}
+
+var _ database.Factory[*Driver] = (*Factory)(nil)
+
+// NewFactory returns a factory creating drivers with opts.
+func NewFactory(client *mongo.Client, opts ...Option) *Factory {
+	return &Factory{client: client, opts: opts}
+}
+
+// Create returns a Driver for the database called name.
+func (f *Factory) Create(_ context.Context, name string) (*Driver, error) {
+	return NewDriver(f.client.Database(name), f.opts...), nil
+}
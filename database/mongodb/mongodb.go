@@ -2,42 +2,79 @@ package mongodb
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"maps"
+	"slices"
 	"sort"
+	"strconv"
+	"sync"
+	"time"
 
 	"github.com/calumari/jwalk"
+	"github.com/go-json-experiment/json"
 	"go.mongodb.org/mongo-driver/v2/bson"
 	"go.mongodb.org/mongo-driver/v2/mongo"
 	"go.mongodb.org/mongo-driver/v2/mongo/options"
+	"go.mongodb.org/mongo-driver/v2/x/mongo/driver/session"
 
 	"github.com/calumari/poutine"
 	"github.com/calumari/poutine/database"
 )
 
 type Driver struct {
-	db *mongo.Database
+	db       *mongo.Database
+	teardown TeardownStrategy
+
+	mu          sync.Mutex
+	seededAt    time.Time
+	seeded      map[string]struct{} // collections written by Seed
+	checkpoints []map[string][]bson.Raw
 }
 
 var (
-	_ poutine.Registrar = (*Driver)(nil)
-	_ database.Driver   = (*Driver)(nil)
+	_ poutine.Registrar      = (*Driver)(nil)
+	_ poutine.Encoder        = (*Driver)(nil)
+	_ database.Driver        = (*Driver)(nil)
+	_ database.Transactional = (*Driver)(nil)
+	_ database.Checkpointer  = (*Driver)(nil)
 )
 
-func NewDriver(db *mongo.Database) *Driver {
+func NewDriver(db *mongo.Database, opts ...Option) *Driver {
+	var o Options
+	for _, opt := range opts {
+		opt(&o)
+	}
 	return &Driver{
-		db: db,
+		db:       db,
+		teardown: o.teardown,
+		seeded:   make(map[string]struct{}),
 	}
 }
 
+// Database returns the database the driver seeds and snapshots.
+func (d *Driver) Database() *mongo.Database {
+	return d.db
+}
+
 func (d *Driver) Seed(ctx context.Context, root jwalk.Document) (jwalk.Document, error) {
 	cols, err := toBSONCollections(root)
 	if err != nil {
 		return nil, fmt.Errorf("convert jwalk to bson: %w", err)
 	}
 
+	d.mu.Lock()
+	d.seededAt = time.Now()
+	for name, docs := range cols {
+		if len(docs) > 0 {
+			d.seeded[name] = struct{}{}
+		}
+	}
+	d.mu.Unlock()
+
 	opts := options.BulkWrite().SetOrdered(false)
 
-	err = d.db.Client().UseSession(ctx, func(ctx context.Context) error {
+	err = d.withSession(ctx, func(ctx context.Context) error {
 		for name, docs := range cols {
 			if len(docs) == 0 {
 				continue
@@ -60,9 +97,9 @@ func (d *Driver) Seed(ctx context.Context, root jwalk.Document) (jwalk.Document,
 }
 
 func (d *Driver) Snapshot(ctx context.Context) (jwalk.Document, error) {
-	colNames, err := d.db.ListCollectionNames(ctx, bson.M{})
+	colNames, err := d.collectionNames(ctx)
 	if err != nil {
-		return nil, fmt.Errorf("list collection names: %w", err)
+		return nil, err
 	}
 
 	actual := make(jwalk.Document, 0, len(colNames))
@@ -94,17 +131,224 @@ func (d *Driver) Snapshot(ctx context.Context) (jwalk.Document, error) {
 	return actual, nil
 }
 
+// Teardown removes the test data according to the driver's teardown
+// strategy, TeardownDropDatabase by default.
 func (d *Driver) Teardown(ctx context.Context) error {
 	return d.db.Client().UseSession(ctx, func(ctx context.Context) error {
-		if err := d.db.Drop(ctx); err != nil {
-			return fmt.Errorf("drop database: %w", err)
+		switch d.teardown {
+		case TeardownDropDatabase:
+			if err := d.db.Drop(ctx); err != nil {
+				return fmt.Errorf("drop database: %w", err)
+			}
+		case TeardownDropSeeded:
+			for _, name := range d.seededNames() {
+				if err := d.db.Collection(name).Drop(ctx); err != nil {
+					return fmt.Errorf("drop collection %q: %w", name, err)
+				}
+			}
+		case TeardownDeleteDocuments:
+			// views and system collections cannot be written to
+			names, err := d.db.ListCollectionNames(ctx, bson.M{
+				"type": "collection",
+				"name": bson.M{"$not": bson.Regex{Pattern: `^system\.`}},
+			})
+			if err != nil {
+				return fmt.Errorf("list collection names: %w", err)
+			}
+			for _, name := range names {
+				if _, err := d.db.Collection(name).DeleteMany(ctx, bson.M{}); err != nil {
+					return fmt.Errorf("delete documents in collection %q: %w", name, err)
+				}
+			}
+		default:
+			return fmt.Errorf("unknown teardown strategy %v", d.teardown)
 		}
+		d.mu.Lock()
+		clear(d.seeded)
+		d.checkpoints = nil
+		d.mu.Unlock()
 		return nil
 	})
 }
 
+// Checkpoint implements database.Checkpointer by copying the documents of
+// every collection. The copies are kept in memory until Teardown.
+func (d *Driver) Checkpoint(ctx context.Context) (string, error) {
+	names, err := d.collectionNames(ctx)
+	if err != nil {
+		return "", err
+	}
+	cp := make(map[string][]bson.Raw, len(names))
+	for _, name := range names {
+		cur, err := d.db.Collection(name).Find(ctx, bson.M{})
+		if err != nil {
+			return "", fmt.Errorf("find in collection %q: %w", name, err)
+		}
+		var docs []bson.Raw
+		if err := cur.All(ctx, &docs); err != nil {
+			return "", fmt.Errorf("copy collection %q: %w", name, err)
+		}
+		cp[name] = docs
+	}
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.checkpoints = append(d.checkpoints, cp)
+	return strconv.Itoa(len(d.checkpoints)), nil
+}
+
+// Restore implements database.Checkpointer. The documents of every
+// checkpointed collection are replaced by their copies, keeping the
+// collection's indexes, and collections created since the checkpoint are
+// dropped, or emptied inside a transaction.
+func (d *Driver) Restore(ctx context.Context, id string) error {
+	d.mu.Lock()
+	n, err := strconv.Atoi(id)
+	if err != nil || n < 1 || n > len(d.checkpoints) {
+		d.mu.Unlock()
+		return fmt.Errorf("unknown checkpoint %q", id)
+	}
+	cp := d.checkpoints[n-1]
+	d.mu.Unlock()
+
+	names, err := d.collectionNames(ctx)
+	if err != nil {
+		return err
+	}
+	inTx := mongo.SessionFromContext(ctx) != nil
+	for _, name := range names {
+		if _, ok := cp[name]; ok {
+			continue
+		}
+		// collections cannot be dropped inside a transaction
+		if inTx {
+			if _, err := d.db.Collection(name).DeleteMany(ctx, bson.M{}); err != nil {
+				return fmt.Errorf("delete documents in collection %q: %w", name, err)
+			}
+			continue
+		}
+		if err := d.db.Collection(name).Drop(ctx); err != nil {
+			return fmt.Errorf("drop collection %q: %w", name, err)
+		}
+	}
+	for _, name := range slices.Sorted(maps.Keys(cp)) {
+		coll := d.db.Collection(name)
+		if !slices.Contains(names, name) {
+			if err := d.db.CreateCollection(ctx, name); err != nil {
+				return fmt.Errorf("create collection %q: %w", name, err)
+			}
+		} else if _, err := coll.DeleteMany(ctx, bson.M{}); err != nil {
+			return fmt.Errorf("delete documents in collection %q: %w", name, err)
+		}
+		if docs := cp[name]; len(docs) > 0 {
+			if _, err := coll.InsertMany(ctx, docs); err != nil {
+				return fmt.Errorf("restore collection %q: %w", name, err)
+			}
+		}
+	}
+	return nil
+}
+
+// Begin implements database.Transactional. It starts a session with a
+// multi-document transaction and returns a context bound to it; operations
+// using the context, including those of the code under test, run inside the
+// transaction. Transactions need a replica set or sharded cluster.
+//
+// Collections cannot be listed inside a transaction, and collections created
+// by an uncommitted transaction cannot be seen outside of it. Snapshot,
+// Checkpoint and Restore therefore only see the collections that existed
+// before Begin and those written by Seed: documents the code under test
+// writes to any other collection are not reported. Create every collection
+// the code under test may write to before Begin, e.g. with the schema set up
+// once per suite.
+func (d *Driver) Begin(ctx context.Context) (context.Context, error) {
+	sess, err := d.db.Client().StartSession()
+	if err != nil {
+		return nil, fmt.Errorf("start session: %w", err)
+	}
+	if err := sess.StartTransaction(); err != nil {
+		sess.EndSession(ctx)
+		return nil, fmt.Errorf("start transaction: %w", err)
+	}
+	return mongo.NewSessionContext(ctx, sess), nil
+}
+
+// Rollback implements database.Transactional, aborting the transaction bound
+// to ctx and ending its session.
+func (d *Driver) Rollback(ctx context.Context) error {
+	sess := mongo.SessionFromContext(ctx)
+	if sess == nil {
+		return errors.New("context is not bound to a session")
+	}
+	defer sess.EndSession(ctx)
+	if err := sess.AbortTransaction(ctx); err != nil && !errors.Is(err, session.ErrAbortTwice) &&
+		!errors.Is(err, session.ErrAbortAfterCommit) && !errors.Is(err, session.ErrNoTransactStarted) {
+		return fmt.Errorf("abort transaction: %w", err)
+	}
+	return nil
+}
+
+// withSession runs fn in the session bound to ctx, or in a new session.
+func (d *Driver) withSession(ctx context.Context, fn func(context.Context) error) error {
+	if mongo.SessionFromContext(ctx) != nil {
+		return fn(ctx)
+	}
+	return d.db.Client().UseSession(ctx, fn)
+}
+
+// collectionNames lists the collections of the database. Collections cannot
+// be listed inside a transaction, so they are listed outside of it and
+// completed with the collections written by Seed, which may only exist in
+// the transaction. Other collections created in the transaction are missed;
+// see Begin.
+func (d *Driver) collectionNames(ctx context.Context) ([]string, error) {
+	inTx := mongo.SessionFromContext(ctx) != nil
+	if inTx {
+		ctx = mongo.NewSessionContext(ctx, nil)
+	}
+	names, err := d.db.ListCollectionNames(ctx, bson.M{})
+	if err != nil {
+		return nil, fmt.Errorf("list collection names: %w", err)
+	}
+	if inTx {
+		d.mu.Lock()
+		for name := range d.seeded {
+			if !slices.Contains(names, name) {
+				names = append(names, name)
+			}
+		}
+		d.mu.Unlock()
+	}
+	return names, nil
+}
+
+// seededNames returns the collections written by Seed in ascending order.
+func (d *Driver) seededNames() []string {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	return slices.Sorted(maps.Keys(d.seeded))
+}
+
 // RegisterTypes implements poutine.Registrar allowing automatic directive
 // registration.
 func (d *Driver) RegisterTypes(reg *jwalk.Registry) error {
-	return reg.Register(ObjectIDDirective)
+	for _, directive := range slices.Concat(directives, []*jwalk.Directive{NewDateDirective(d.seedTime)}) {
+		if err := reg.Register(directive); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Marshalers implements poutine.Encoder, encoding BSON snapshot values as the
+// directives registered by RegisterTypes.
+func (d *Driver) Marshalers() *json.Marshalers {
+	return Marshalers
+}
+
+// seedTime returns when Seed was last called, or the zero time.
+func (d *Driver) seedTime() time.Time {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	return d.seededAt
 }
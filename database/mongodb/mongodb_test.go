@@ -4,8 +4,10 @@ import (
 	"context"
 	"fmt"
 	"testing"
+	"time"
 
 	"github.com/calumari/jwalk"
+	"github.com/calumari/testequals"
 	"github.com/google/uuid"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
@@ -16,7 +18,10 @@ import (
 	"go.mongodb.org/mongo-driver/v2/mongo"
 	"go.mongodb.org/mongo-driver/v2/mongo/options"
 
+	"github.com/calumari/poutine/database"
+	"github.com/calumari/poutine/database/drivertest"
 	"github.com/calumari/poutine/database/mongodb"
+	"github.com/calumari/poutine/testine"
 )
 
 type MongoSuite struct {
@@ -67,12 +72,12 @@ func TestPetStoreRepoSuite(t *testing.T) {
 }
 
 // helper to create a new driver with a unique database per subtest
-func (s *MongoSuite) newDriver(t *testing.T) (*mongodb.Driver, *mongo.Database) {
+func (s *MongoSuite) newDriver(t *testing.T, opts ...mongodb.Option) (*mongodb.Driver, *mongo.Database) {
 	t.Helper()
 	// MongoDB database names must be <= 63 chars; use short prefix + 8 char suffix
 	dbName := fmt.Sprintf("pmdt_%s", uuid.NewString()[:8])
 	db := s.client.Database(dbName)
-	return mongodb.NewDriver(db), db
+	return mongodb.NewDriver(db, opts...), db
 }
 
 func (s *MongoSuite) TestDriver_Seed() {
@@ -113,6 +118,24 @@ func (s *MongoSuite) TestDriver_Seed() {
 		assert.Equal(t, bson.D{{Key: "_id", Value: "u2"}, {Key: "name", Value: "Bob"}}, docs[1])
 	})
 
+	s.Run("wildcard timestamp is stored as seeded", func() {
+		t := s.T()
+		driver, _ := s.newDriver(t)
+		reg, err := jwalk.NewRegistry()
+		require.NoError(t, err)
+		require.NoError(t, driver.RegisterTypes(reg))
+
+		var root jwalk.Document
+		err = reg.Unmarshal([]byte(`{"events": [{"_id": 1, "ts": {"$timestamp": true}}]}`), &root)
+		require.NoError(t, err)
+		seeded, err := driver.Seed(t.Context(), root)
+		require.NoError(t, err)
+		got, err := driver.Snapshot(t.Context())
+		require.NoError(t, err)
+		ts := seeded[0].Value.(jwalk.Array)[0].(jwalk.Document)[1].Value.(interface{ UnwrapValue() any }).UnwrapValue()
+		assert.Equal(t, ts, got[0].Value.(jwalk.Array)[0].(jwalk.Document)[1].Value)
+	})
+
 	s.Run("seed empty document creates no collections", func() {
 		t := s.T()
 		driver, db := s.newDriver(t)
@@ -173,6 +196,46 @@ func (s *MongoSuite) TestDriver_Teardown() {
 		require.NoError(t, err)
 		assert.Empty(t, colsAfter)
 	})
+
+	s.Run("drop seeded keeps other collections", func() {
+		t := s.T()
+		driver, db := s.newDriver(t, mongodb.WithTeardown(mongodb.TeardownDropSeeded))
+		t.Cleanup(func() { _ = db.Drop(context.Background()) })
+
+		require.NoError(t, db.CreateCollection(t.Context(), "migrations"))
+		_, err := driver.Seed(t.Context(), jwalk.Document{
+			{Key: "posts", Value: jwalk.Array{jwalk.Document{{Key: "title", Value: "Hello"}}}},
+		})
+		require.NoError(t, err)
+
+		require.NoError(t, driver.Teardown(t.Context()))
+
+		cols, err := db.ListCollectionNames(t.Context(), bson.M{})
+		require.NoError(t, err)
+		assert.Equal(t, []string{"migrations"}, cols)
+	})
+
+	s.Run("delete documents keeps collections and indexes", func() {
+		t := s.T()
+		driver, db := s.newDriver(t, mongodb.WithTeardown(mongodb.TeardownDeleteDocuments))
+		t.Cleanup(func() { _ = db.Drop(context.Background()) })
+
+		_, err := db.Collection("posts").Indexes().CreateOne(t.Context(), mongo.IndexModel{Keys: bson.D{{Key: "title", Value: 1}}})
+		require.NoError(t, err)
+		_, err = driver.Seed(t.Context(), jwalk.Document{
+			{Key: "posts", Value: jwalk.Array{jwalk.Document{{Key: "title", Value: "Hello"}}}},
+		})
+		require.NoError(t, err)
+
+		require.NoError(t, driver.Teardown(t.Context()))
+
+		count, err := db.Collection("posts").CountDocuments(t.Context(), bson.M{})
+		require.NoError(t, err)
+		assert.Zero(t, count)
+		specs, err := db.Collection("posts").Indexes().ListSpecifications(t.Context())
+		require.NoError(t, err)
+		assert.Len(t, specs, 2, "_id and title indexes")
+	})
 }
 
 func (s *MongoSuite) TestDriver_RegisterTypes() {
@@ -190,3 +253,159 @@ func (s *MongoSuite) TestDriver_RegisterTypes() {
 		assert.Error(t, err)
 	})
 }
+
+func (s *MongoSuite) TestDriver_DateDirective() {
+	s.Run("relative date matches document written after seed", func() {
+		t := s.T()
+		driver, db := s.newDriver(t)
+		reg, err := jwalk.NewRegistry()
+		require.NoError(t, err)
+		require.NoError(t, driver.RegisterTypes(reg))
+
+		_, err = driver.Seed(t.Context(), jwalk.Document{})
+		require.NoError(t, err)
+		_, err = db.Collection("events").InsertOne(t.Context(), bson.D{{Key: "createdAt", Value: bson.NewDateTimeFromTime(time.Now())}})
+		require.NoError(t, err)
+
+		var expected jwalk.Document
+		err = reg.Unmarshal([]byte(`{"events": [{"createdAt": {"$date": {"after": "seed", "before": "now"}}}]}`), &expected)
+		require.NoError(t, err)
+		got, err := driver.Snapshot(t.Context())
+		require.NoError(t, err)
+		assert.NoError(t, testequals.New().Test(expected, got))
+	})
+}
+
+func (s *MongoSuite) TestDriver_Conformance() {
+	drivertest.RunConformance(s.T(), func(t *testing.T) database.Driver {
+		driver, db := s.newDriver(t)
+		t.Cleanup(func() { _ = db.Drop(context.Background()) })
+		return driver
+	})
+}
+
+func (s *MongoSuite) TestFactory_Pool() {
+	pool := testine.NewPool(mongodb.NewFactory(s.client))
+	var name string
+	s.Run("each test gets its own database", func() {
+		t := s.T()
+		pt := pool.For(t)
+		db := pool.Driver(t).Database()
+		name = db.Name()
+
+		pt.Seed(t, jwalk.Document{{Key: "users", Value: jwalk.Array{
+			jwalk.Document{{Key: "_id", Value: "u1"}},
+		}}})
+		n, err := db.Collection("users").CountDocuments(t.Context(), bson.M{})
+		require.NoError(t, err)
+		assert.Equal(t, int64(1), n)
+	})
+
+	t := s.T()
+	names, err := s.client.ListDatabaseNames(t.Context(), bson.M{"name": name})
+	require.NoError(t, err)
+	assert.Empty(t, names, "database %s was not dropped", name)
+}
+
+func (s *MongoSuite) TestDriver_Checkpoint() {
+	s.Run("restore returns to checkpointed state", func() {
+		t := s.T()
+		driver, db := s.newDriver(t)
+		t.Cleanup(func() { _ = db.Drop(context.Background()) })
+
+		_, err := db.Collection("posts").Indexes().CreateOne(t.Context(), mongo.IndexModel{Keys: bson.D{{Key: "title", Value: 1}}})
+		require.NoError(t, err)
+		_, err = driver.Seed(t.Context(), jwalk.Document{
+			{Key: "posts", Value: jwalk.Array{jwalk.Document{{Key: "_id", Value: "p1"}, {Key: "title", Value: "Hello"}}}},
+		})
+		require.NoError(t, err)
+		id, err := driver.Checkpoint(t.Context())
+		require.NoError(t, err)
+
+		_, err = db.Collection("posts").DeleteMany(t.Context(), bson.M{})
+		require.NoError(t, err)
+		_, err = db.Collection("comments").InsertOne(t.Context(), bson.D{{Key: "_id", Value: "c1"}})
+		require.NoError(t, err)
+		require.NoError(t, driver.Restore(t.Context(), id))
+
+		got, err := driver.Snapshot(t.Context())
+		require.NoError(t, err)
+		assert.Equal(t, jwalk.Document{
+			{Key: "posts", Value: jwalk.Array{jwalk.Document{{Key: "_id", Value: "p1"}, {Key: "title", Value: "Hello"}}}},
+		}, got)
+		specs, err := db.Collection("posts").Indexes().ListSpecifications(t.Context())
+		require.NoError(t, err)
+		assert.Len(t, specs, 2, "indexes are kept")
+	})
+
+	s.Run("unknown checkpoint returns error", func() {
+		t := s.T()
+		driver, _ := s.newDriver(t)
+		assert.Error(t, driver.Restore(t.Context(), "1"))
+	})
+}
+
+func (s *MongoSuite) TestDriver_Transactional() {
+	t := s.T()
+	var hello bson.M
+	require.NoError(t, s.client.Database("admin").RunCommand(t.Context(), bson.D{{Key: "hello", Value: 1}}).Decode(&hello))
+	if _, ok := hello["setName"]; !ok {
+		t.Skip("transactions need a replica set")
+	}
+
+	s.Run("rollback discards seeded and written documents", func() {
+		t := s.T()
+		driver, db := s.newDriver(t)
+		t.Cleanup(func() { _ = db.Drop(context.Background()) })
+		_, err := db.Collection("users").InsertOne(t.Context(), bson.D{{Key: "_id", Value: "u0"}})
+		require.NoError(t, err)
+
+		ctx, err := driver.Begin(t.Context())
+		require.NoError(t, err)
+		_, err = driver.Seed(ctx, jwalk.Document{
+			{Key: "users", Value: jwalk.Array{jwalk.Document{{Key: "_id", Value: "u1"}}}},
+			{Key: "pets", Value: jwalk.Array{jwalk.Document{{Key: "_id", Value: "p1"}}}},
+		})
+		require.NoError(t, err)
+		_, err = db.Collection("users").InsertOne(ctx, bson.D{{Key: "_id", Value: "u2"}})
+		require.NoError(t, err)
+
+		got, err := driver.Snapshot(ctx)
+		require.NoError(t, err)
+		assert.Equal(t, jwalk.Document{
+			{Key: "pets", Value: jwalk.Array{jwalk.Document{{Key: "_id", Value: "p1"}}}},
+			{Key: "users", Value: jwalk.Array{
+				jwalk.Document{{Key: "_id", Value: "u0"}},
+				jwalk.Document{{Key: "_id", Value: "u1"}},
+				jwalk.Document{{Key: "_id", Value: "u2"}},
+			}},
+		}, got)
+
+		require.NoError(t, driver.Rollback(ctx))
+		n, err := db.Collection("users").CountDocuments(t.Context(), bson.M{})
+		require.NoError(t, err)
+		assert.Equal(t, int64(1), n)
+		assert.NoError(t, driver.Rollback(ctx), "second rollback is not an error")
+	})
+
+	s.Run("snapshot misses collections created in the transaction", func() {
+		t := s.T()
+		driver, db := s.newDriver(t)
+		t.Cleanup(func() { _ = db.Drop(context.Background()) })
+		require.NoError(t, db.CreateCollection(t.Context(), "audit"))
+
+		ctx, err := driver.Begin(t.Context())
+		require.NoError(t, err)
+		_, err = db.Collection("audit").InsertOne(ctx, bson.D{{Key: "_id", Value: "a1"}})
+		require.NoError(t, err)
+		_, err = db.Collection("unseeded").InsertOne(ctx, bson.D{{Key: "_id", Value: "x1"}})
+		require.NoError(t, err)
+
+		got, err := driver.Snapshot(ctx)
+		require.NoError(t, err)
+		assert.Equal(t, jwalk.Document{
+			{Key: "audit", Value: jwalk.Array{jwalk.Document{{Key: "_id", Value: "a1"}}}},
+		}, got, "collections created before Begin are seen, others are not")
+		require.NoError(t, driver.Rollback(ctx))
+	})
+}
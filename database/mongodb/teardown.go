@@ -0,0 +1,45 @@
+package mongodb
+
+import "fmt"
+
+// TeardownStrategy controls what Driver.Teardown removes.
+type TeardownStrategy int
+
+const (
+	// TeardownDropDatabase drops the whole database, including indexes,
+	// validators and collections created outside of Seed.
+	TeardownDropDatabase TeardownStrategy = iota
+	// TeardownDropSeeded drops only the collections written by Seed since the
+	// last teardown. Other collections, and their indexes, are kept.
+	TeardownDropSeeded
+	// TeardownDeleteDocuments deletes the documents of every collection,
+	// keeping the collections with their indexes and validators.
+	TeardownDeleteDocuments
+)
+
+func (s TeardownStrategy) String() string {
+	switch s {
+	case TeardownDropDatabase:
+		return "drop database"
+	case TeardownDropSeeded:
+		return "drop seeded collections"
+	case TeardownDeleteDocuments:
+		return "delete documents"
+	default:
+		return fmt.Sprintf("TeardownStrategy(%d)", int(s))
+	}
+}
+
+// Options configures a Driver.
+type Options struct {
+	teardown TeardownStrategy
+}
+
+type Option func(*Options)
+
+// WithTeardown sets how Teardown removes the test data, so schema set up once
+// per suite, such as indexes and validators, can survive between tests. The
+// default is TeardownDropDatabase.
+func WithTeardown(s TeardownStrategy) Option {
+	return func(o *Options) { o.teardown = s }
+}
@@ -1,37 +1,330 @@
 package mongodb
 
 import (
+	"encoding/base64"
+	"errors"
 	"fmt"
+	"math"
+	"strconv"
+	"time"
 
 	"github.com/calumari/jwalk"
+	"github.com/calumari/testequals"
 	"github.com/go-json-experiment/json"
 	"github.com/go-json-experiment/json/jsontext"
+	"github.com/google/uuid"
 	"go.mongodb.org/mongo-driver/v2/bson"
 
 	"github.com/calumari/poutine/exp"
 )
 
-var ObjectIDDirective = jwalk.NewDirective("oid", unmarshalOIDPattern)
+var (
+	ObjectIDDirective  = jwalk.NewDirective("oid", unmarshalOIDPattern)
+	DateDirective      = NewDateDirective(nil)
+	DecimalDirective   = jwalk.NewDirective("numberDecimal", unmarshalDecimalPattern)
+	BinaryDirective    = jwalk.NewDirective("binary", unmarshalBinaryPattern)
+	TimestampDirective = jwalk.NewDirective("timestamp", unmarshalTimestampPattern)
+	RegexDirective     = jwalk.NewDirective("regularExpression", unmarshalRegexPattern)
+	LongDirective      = jwalk.NewDirective("numberLong", unmarshalLongPattern)
+	IntDirective       = jwalk.NewDirective("numberInt", unmarshalIntPattern)
+	DoubleDirective    = jwalk.NewDirective("numberDouble", unmarshalDoublePattern)
+	UUIDDirective      = jwalk.NewDirective("uuid", unmarshalUUIDPattern)
+	MinKeyDirective    = jwalk.NewDirective("minKey", unmarshalMinKeyPattern)
+	MaxKeyDirective    = jwalk.NewDirective("maxKey", unmarshalMaxKeyPattern)
+	CodeDirective      = jwalk.NewDirective("code", unmarshalCodePattern)
+	SymbolDirective    = jwalk.NewDirective("symbol", unmarshalSymbolPattern)
+	UndefinedDirective = jwalk.NewDirective("undefined", unmarshalUndefinedPattern)
+	DBPointerDirective = jwalk.NewDirective("dbPointer", unmarshalDBPointerPattern)
+)
+
+// directives lists the directives registered by Driver.RegisterTypes besides
+// the driver-bound $date directive.
+var directives = []*jwalk.Directive{
+	ObjectIDDirective,
+	DecimalDirective,
+	BinaryDirective,
+	TimestampDirective,
+	RegexDirective,
+	LongDirective,
+	IntDirective,
+	DoubleDirective,
+	UUIDDirective,
+	MinKeyDirective,
+	MaxKeyDirective,
+	CodeDirective,
+	SymbolDirective,
+	UndefinedDirective,
+	DBPointerDirective,
+}
 
 func unmarshalOIDPattern(dec *jsontext.Decoder) (exp.Pattern[bson.ObjectID], error) {
-	var raw any
-	if err := json.UnmarshalDecode(dec, &raw); err != nil {
-		return exp.Pattern[bson.ObjectID]{}, err
-	}
-	switch v := raw.(type) {
-	case bool:
-		if !v {
-			return exp.Pattern[bson.ObjectID]{}, fmt.Errorf("$oid bool must be true to indicate wildcard")
-		}
-		// wildcard presence (no explicit user value)
-		return exp.Any(bson.NewObjectID()), nil
-	case string:
-		oid, err := bson.ObjectIDFromHex(v)
+	return unmarshalWildcard(dec, "oid", bson.NewObjectID(), bson.ObjectIDFromHex)
+}
+
+// binaryPayload is the Extended JSON form of a bson.Binary.
+type binaryPayload struct {
+	Base64  string `json:"base64"`
+	SubType string `json:"subType"`
+}
+
+// timestampPayload is the Extended JSON form of a bson.Timestamp.
+type timestampPayload struct {
+	T uint32 `json:"t"`
+	I uint32 `json:"i"`
+}
+
+// regexPayload is the Extended JSON form of a bson.Regex.
+type regexPayload struct {
+	Pattern string `json:"pattern"`
+	Options string `json:"options"`
+}
+
+// referenceable lists the directives whose string payload may name a
+// reference ("@name") or capture ("?name") instead of a value. They are the
+// types typically generated for identifiers and timestamps.
+var referenceable = map[string]bool{
+	"oid":        true,
+	"uuid":       true,
+	"numberLong": true,
+	"numberInt":  true,
+	"date":       true,
+}
+
+// unmarshalWildcard decodes a directive payload. A literal true yields a
+// wildcard pattern around placeholder; any other payload is decoded into P
+// and converted by parse.
+func unmarshalWildcard[T, P any](dec *jsontext.Decoder, name string, placeholder T, parse func(P) (T, error)) (exp.Pattern[T], error) {
+	raw, err := dec.ReadValue()
+	if err != nil {
+		return exp.Pattern[T]{}, err
+	}
+	switch raw.Kind() {
+	case 't':
+		return exp.Any(placeholder), nil
+	case 'f':
+		return exp.Pattern[T]{}, fmt.Errorf("$%s bool must be true to indicate wildcard", name)
+	case '"':
+		if referenceable[name] {
+			var s string
+			if err := json.Unmarshal(raw, &s); err != nil {
+				return exp.Pattern[T]{}, err
+			}
+			if ref, ok := exp.ParseRef(s, placeholder); ok {
+				return ref, nil
+			}
+		}
+	}
+	var payload P
+	if err := json.Unmarshal(raw, &payload); err != nil {
+		return exp.Pattern[T]{}, fmt.Errorf("invalid $%s payload: %w", name, err)
+	}
+	v, err := parse(payload)
+	if err != nil {
+		return exp.Pattern[T]{}, err
+	}
+	return exp.Value(v), nil
+}
+
+func unmarshalDatePattern(dec *jsontext.Decoder) (exp.Pattern[bson.DateTime], error) {
+	return unmarshalWildcard(dec, "date", bson.NewDateTimeFromTime(time.Now()), func(s string) (bson.DateTime, error) {
+		t, err := time.Parse(time.RFC3339Nano, s)
 		if err != nil {
-			return exp.Pattern[bson.ObjectID]{}, err
+			return 0, err
 		}
-		return exp.Value(oid), nil
-	default:
-		return exp.Pattern[bson.ObjectID]{}, fmt.Errorf("invalid $oid payload type %T", v)
+		return bson.NewDateTimeFromTime(t), nil
+	})
+}
+
+func unmarshalDecimalPattern(dec *jsontext.Decoder) (exp.Pattern[bson.Decimal128], error) {
+	return unmarshalWildcard(dec, "numberDecimal", bson.NewDecimal128(0, 0), bson.ParseDecimal128)
+}
+
+func unmarshalBinaryPattern(dec *jsontext.Decoder) (exp.Pattern[bson.Binary], error) {
+	return unmarshalWildcard(dec, "binary", bson.Binary{}, func(p binaryPayload) (bson.Binary, error) {
+		data, err := base64.StdEncoding.DecodeString(p.Base64)
+		if err != nil {
+			return bson.Binary{}, err
+		}
+		subtype, err := strconv.ParseUint(p.SubType, 16, 8)
+		if err != nil {
+			return bson.Binary{}, fmt.Errorf("invalid $binary subType %q: %w", p.SubType, err)
+		}
+		return bson.Binary{Subtype: byte(subtype), Data: data}, nil
+	})
+}
+
+// unmarshalTimestampPattern decodes a bson.Timestamp. The wildcard placeholder
+// is the current second: the server replaces a zero timestamp with its own
+// when it is inserted, so the seeded and expected values would differ.
+func unmarshalTimestampPattern(dec *jsontext.Decoder) (exp.Pattern[bson.Timestamp], error) {
+	placeholder := bson.Timestamp{T: uint32(time.Now().Unix()), I: 1}
+	return unmarshalWildcard(dec, "timestamp", placeholder, func(p timestampPayload) (bson.Timestamp, error) {
+		return bson.Timestamp{T: p.T, I: p.I}, nil
+	})
+}
+
+func unmarshalRegexPattern(dec *jsontext.Decoder) (exp.Pattern[bson.Regex], error) {
+	return unmarshalWildcard(dec, "regularExpression", bson.Regex{}, func(p regexPayload) (bson.Regex, error) {
+		return bson.Regex{Pattern: p.Pattern, Options: p.Options}, nil
+	})
+}
+
+func unmarshalLongPattern(dec *jsontext.Decoder) (exp.Pattern[int64], error) {
+	return unmarshalWildcard(dec, "numberLong", int64(0), func(s string) (int64, error) {
+		return strconv.ParseInt(s, 10, 64)
+	})
+}
+
+func unmarshalIntPattern(dec *jsontext.Decoder) (exp.Pattern[int32], error) {
+	return unmarshalWildcard(dec, "numberInt", int32(0), func(s string) (int32, error) {
+		n, err := strconv.ParseInt(s, 10, 32)
+		return int32(n), err
+	})
+}
+
+// unmarshalDoublePattern accepts decimal strings as well as "Infinity",
+// "-Infinity" and "NaN".
+func unmarshalDoublePattern(dec *jsontext.Decoder) (exp.Pattern[float64], error) {
+	return unmarshalWildcard(dec, "numberDouble", float64(0), func(s string) (float64, error) {
+		switch s {
+		case "Infinity":
+			return math.Inf(1), nil
+		case "-Infinity":
+			return math.Inf(-1), nil
+		case "NaN":
+			return math.NaN(), nil
+		default:
+			return strconv.ParseFloat(s, 64)
+		}
+	})
+}
+
+// unmarshalUUIDPattern decodes a UUID string into a binary value of subtype 4.
+func unmarshalUUIDPattern(dec *jsontext.Decoder) (uuidPattern, error) {
+	placeholder := uuid.New()
+	p, err := unmarshalWildcard(dec, "uuid", uuidBinary(placeholder), func(s string) (bson.Binary, error) {
+		id, err := uuid.Parse(s)
+		if err != nil {
+			return bson.Binary{}, err
+		}
+		return uuidBinary(id), nil
+	})
+	return uuidPattern{p}, err
+}
+
+// uuidPattern is a $uuid pattern. Unlike a plain binary pattern, its wildcard
+// only matches binary values of subtype 4.
+type uuidPattern struct {
+	exp.Pattern[bson.Binary]
+}
+
+var (
+	_ testequals.Rule = uuidPattern{}
+	_ exp.Bindable    = uuidPattern{}
+)
+
+// Bind implements exp.Bindable, keeping the subtype check of named wildcards.
+func (p uuidPattern) Bind(b *exp.Bindings) any {
+	return uuidPattern{p.Pattern.Bind(b).(exp.Pattern[bson.Binary])}
+}
+
+func (p uuidPattern) Test(rc *testequals.RuleContext, actual any) error {
+	if bin, ok := actual.(bson.Binary); ok && p.IsWildcard() && bin.Subtype != bson.TypeBinaryUUID {
+		return fmt.Errorf("expected binary subtype %02x, got %02x", bson.TypeBinaryUUID, bin.Subtype)
 	}
+	return p.Pattern.Test(rc, actual)
+}
+
+func uuidBinary(id uuid.UUID) bson.Binary {
+	return bson.Binary{Subtype: bson.TypeBinaryUUID, Data: id[:]}
+}
+
+func unmarshalMinKeyPattern(dec *jsontext.Decoder) (exp.Pattern[bson.MinKey], error) {
+	return unmarshalWildcard(dec, "minKey", bson.MinKey{}, func(n int) (bson.MinKey, error) {
+		if n != 1 {
+			return bson.MinKey{}, fmt.Errorf("$minKey must be 1, got %d", n)
+		}
+		return bson.MinKey{}, nil
+	})
+}
+
+func unmarshalMaxKeyPattern(dec *jsontext.Decoder) (exp.Pattern[bson.MaxKey], error) {
+	return unmarshalWildcard(dec, "maxKey", bson.MaxKey{}, func(n int) (bson.MaxKey, error) {
+		if n != 1 {
+			return bson.MaxKey{}, fmt.Errorf("$maxKey must be 1, got %d", n)
+		}
+		return bson.MaxKey{}, nil
+	})
+}
+
+// unmarshalCodePattern decodes {"$code": "..."} into bson.JavaScript, or
+// {"$code": "...", "$scope": {...}} into bson.CodeWithScope. jwalk calls it
+// with dec positioned after the "$code" name, inside the directive object, and
+// skips whatever members the directive leaves unread, so $scope is read from
+// the same object and any other member is rejected here. "$code" must
+// therefore come first.
+func unmarshalCodePattern(dec *jsontext.Decoder) (any, error) {
+	code, err := unmarshalWildcard(dec, "code", bson.JavaScript(""), func(s string) (bson.JavaScript, error) {
+		return bson.JavaScript(s), nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	if dec.PeekKind() != '"' {
+		return code, nil
+	}
+	tok, err := dec.ReadToken()
+	if err != nil {
+		return nil, err
+	}
+	if key := tok.String(); key != "$scope" {
+		return nil, fmt.Errorf("unexpected key %q after $code", key)
+	}
+	raw, err := dec.ReadValue()
+	if err != nil {
+		return nil, err
+	}
+	var scope bson.D
+	if err := bson.UnmarshalExtJSON(raw, true, &scope); err != nil {
+		return nil, fmt.Errorf("invalid $scope: %w", err)
+	}
+	if dec.PeekKind() == '"' {
+		return nil, errors.New("unexpected key after $scope")
+	}
+	if code.IsWildcard() {
+		return nil, fmt.Errorf("$code wildcard cannot have a $scope")
+	}
+	return exp.Value(bson.CodeWithScope{Code: code.Value(), Scope: scope}), nil
+}
+
+func unmarshalSymbolPattern(dec *jsontext.Decoder) (exp.Pattern[bson.Symbol], error) {
+	return unmarshalWildcard(dec, "symbol", bson.Symbol(""), func(s string) (bson.Symbol, error) {
+		return bson.Symbol(s), nil
+	})
+}
+
+// unmarshalUndefinedPattern decodes {"$undefined": true}, the only valid
+// form, so it doubles as the wildcard.
+func unmarshalUndefinedPattern(dec *jsontext.Decoder) (exp.Pattern[bson.Undefined], error) {
+	return unmarshalWildcard(dec, "undefined", bson.Undefined{}, func(bool) (bson.Undefined, error) {
+		return bson.Undefined{}, nil
+	})
+}
+
+// dbPointerPayload is the Extended JSON form of a bson.DBPointer.
+type dbPointerPayload struct {
+	Ref string `json:"$ref"`
+	ID  struct {
+		OID string `json:"$oid"`
+	} `json:"$id"`
+}
+
+func unmarshalDBPointerPattern(dec *jsontext.Decoder) (exp.Pattern[bson.DBPointer], error) {
+	return unmarshalWildcard(dec, "dbPointer", bson.DBPointer{}, func(p dbPointerPayload) (bson.DBPointer, error) {
+		oid, err := bson.ObjectIDFromHex(p.ID.OID)
+		if err != nil {
+			return bson.DBPointer{}, err
+		}
+		return bson.DBPointer{DB: p.Ref, Pointer: oid}, nil
+	})
 }
@@ -0,0 +1,85 @@
+package postgres
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/calumari/poutine/database/internal/sqldb"
+)
+
+// dialect qualifies tables with the driver schema and numbers placeholders
+// $1, $2, ...
+type dialect struct {
+	schema string
+}
+
+var _ sqldb.Dialect = dialect{}
+
+func (d dialect) Table(name string) string {
+	if d.schema == "" {
+		return sqldb.QuoteIdent(name)
+	}
+	return sqldb.QuoteIdent(d.schema) + "." + sqldb.QuoteIdent(name)
+}
+
+func (dialect) Placeholder(i int) string {
+	return "$" + strconv.Itoa(i+1)
+}
+
+// primaryKeyQuery lists the primary key columns of a table in key order.
+const primaryKeyQuery = `
+SELECT a.attname
+FROM pg_index i
+JOIN pg_attribute a ON a.attrelid = i.indrelid AND a.attnum = ANY(i.indkey)
+WHERE i.indrelid = $1::regclass AND i.indisprimary
+ORDER BY array_position(i.indkey::int2[], a.attnum)`
+
+// OrderBy orders rows by the primary key of the table, or by ctid when it has
+// none.
+func (d dialect) OrderBy(ctx context.Context, q sqldb.Querier, name string) ([]string, error) {
+	cols, err := sqldb.Columns(ctx, q, primaryKeyQuery, d.Table(name))
+	if err != nil {
+		return nil, fmt.Errorf("primary key: %w", err)
+	}
+	if len(cols) == 0 {
+		return []string{"ctid"}, nil
+	}
+	return cols, nil
+}
+
+// Value converts a scanned column value into the type used by fixtures:
+// uuid.UUID for UUID columns, UTC time.Time for timestamps and jwalk
+// documents/arrays for JSON columns.
+func (dialect) Value(typeName string, v any) (any, error) {
+	if v == nil {
+		return nil, nil
+	}
+	switch typeName {
+	case "UUID":
+		switch val := v.(type) {
+		case string:
+			return uuid.Parse(val)
+		case []byte:
+			return uuid.ParseBytes(val)
+		case [16]byte:
+			return uuid.UUID(val), nil
+		}
+	case "JSON", "JSONB":
+		return sqldb.DecodeJSON(v)
+	}
+	switch val := v.(type) {
+	case time.Time:
+		return val.UTC(), nil
+	case []byte:
+		if typeName == "BYTEA" {
+			return val, nil
+		}
+		return string(val), nil
+	default:
+		return v, nil
+	}
+}
@@ -0,0 +1,83 @@
+package postgres
+
+import (
+	"testing"
+	"time"
+
+	"github.com/calumari/jwalk"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_dialect(t *testing.T) {
+	t.Run("table is qualified with schema", func(t *testing.T) {
+		assert.Equal(t, `"public"."users"`, dialect{schema: "public"}.Table("users"))
+		assert.Equal(t, `"we""ird"`, dialect{}.Table(`we"ird`))
+	})
+
+	t.Run("placeholders are numbered from one", func(t *testing.T) {
+		assert.Equal(t, "$1", dialect{}.Placeholder(0))
+		assert.Equal(t, "$3", dialect{}.Placeholder(2))
+	})
+}
+
+func Test_dialect_Value(t *testing.T) {
+	t.Run("nil returns nil", func(t *testing.T) {
+		got, err := dialect{}.Value("TEXT", nil)
+		require.NoError(t, err)
+		assert.Nil(t, got)
+	})
+
+	t.Run("uuid string returns uuid", func(t *testing.T) {
+		id := uuid.New()
+		got, err := dialect{}.Value("UUID", id.String())
+		require.NoError(t, err)
+		assert.Equal(t, id, got)
+	})
+
+	t.Run("invalid uuid returns error", func(t *testing.T) {
+		_, err := dialect{}.Value("UUID", "nope")
+		assert.Error(t, err)
+	})
+
+	t.Run("jsonb returns jwalk document with sorted keys", func(t *testing.T) {
+		got, err := dialect{}.Value("JSONB", []byte(`{"b":[1,{"c":true}],"a":"x"}`))
+		require.NoError(t, err)
+		want := jwalk.Document{
+			{Key: "a", Value: "x"},
+			{Key: "b", Value: jwalk.Array{1.0, jwalk.Document{{Key: "c", Value: true}}}},
+		}
+		assert.Equal(t, want, got)
+	})
+
+	t.Run("invalid json returns error", func(t *testing.T) {
+		_, err := dialect{}.Value("JSON", "{")
+		assert.Error(t, err)
+	})
+
+	t.Run("time converts to utc", func(t *testing.T) {
+		in := time.Date(2024, 1, 2, 3, 4, 5, 0, time.FixedZone("X", 3600))
+		got, err := dialect{}.Value("TIMESTAMPTZ", in)
+		require.NoError(t, err)
+		assert.Equal(t, in.UTC(), got)
+	})
+
+	t.Run("text bytes return string", func(t *testing.T) {
+		got, err := dialect{}.Value("NUMERIC", []byte("1.50"))
+		require.NoError(t, err)
+		assert.Equal(t, "1.50", got)
+	})
+
+	t.Run("bytea bytes return bytes", func(t *testing.T) {
+		got, err := dialect{}.Value("BYTEA", []byte{1, 2})
+		require.NoError(t, err)
+		assert.Equal(t, []byte{1, 2}, got)
+	})
+
+	t.Run("other value returns itself", func(t *testing.T) {
+		got, err := dialect{}.Value("INT8", int64(7))
+		require.NoError(t, err)
+		assert.Equal(t, int64(7), got)
+	})
+}
@@ -0,0 +1,34 @@
+package postgres
+
+import (
+	"time"
+
+	"github.com/go-json-experiment/json"
+	"github.com/go-json-experiment/json/jsontext"
+	"github.com/google/uuid"
+)
+
+// Marshalers encodes column values as the directives registered by
+// Driver.RegisterTypes.
+var Marshalers = json.JoinMarshalers(
+	json.MarshalToFunc(func(enc *jsontext.Encoder, v uuid.UUID) error {
+		return writeDirective(enc, "uuid", v.String())
+	}),
+	json.MarshalToFunc(func(enc *jsontext.Encoder, v time.Time) error {
+		return writeDirective(enc, "timestamptz", v.UTC().Format(time.RFC3339Nano))
+	}),
+)
+
+// writeDirective writes {"$name": value}.
+func writeDirective(enc *jsontext.Encoder, name, value string) error {
+	if err := enc.WriteToken(jsontext.BeginObject); err != nil {
+		return err
+	}
+	if err := enc.WriteToken(jsontext.String("$" + name)); err != nil {
+		return err
+	}
+	if err := enc.WriteToken(jsontext.String(value)); err != nil {
+		return err
+	}
+	return enc.WriteToken(jsontext.EndObject)
+}
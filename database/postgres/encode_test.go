@@ -0,0 +1,37 @@
+package postgres
+
+import (
+	"testing"
+	"time"
+
+	"github.com/calumari/jwalk"
+	"github.com/go-json-experiment/json"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_Marshalers(t *testing.T) {
+	id := uuid.MustParse("2f1e2d3c-4b5a-4968-8776-655443322110")
+	ts := time.Date(2024, 1, 2, 3, 4, 5, 6000, time.UTC)
+
+	t.Run("encodes directives and round trips", func(t *testing.T) {
+		row := jwalk.Document{{Key: "id", Value: id}, {Key: "created_at", Value: ts}, {Key: "n", Value: 3}}
+		data, err := json.Marshal(map[string]any{"id": id, "created_at": ts}, json.WithMarshalers(Marshalers), json.Deterministic(true))
+		require.NoError(t, err)
+		assert.Equal(t, `{"created_at":{"$timestamptz":"2024-01-02T03:04:05.000006Z"},"id":{"$uuid":"2f1e2d3c-4b5a-4968-8776-655443322110"}}`, string(data))
+
+		doc, err := decodeWith(t, `{"t":[{"id":{"$uuid":"`+id.String()+`"},"created_at":{"$timestamptz":"2024-01-02T03:04:05.000006Z"},"n":3}]}`)
+		require.NoError(t, err)
+		got := doc[0].Value.(jwalk.Array)[0].(jwalk.Document)
+		for i, e := range got[:2] {
+			assert.Equal(t, row[i].Value, e.Value.(interface{ UnwrapValue() any }).UnwrapValue())
+		}
+	})
+
+	t.Run("non-UTC time encodes in UTC", func(t *testing.T) {
+		data, err := json.Marshal(ts.In(time.FixedZone("X", 3600)), json.WithMarshalers(Marshalers))
+		require.NoError(t, err)
+		assert.Equal(t, `{"$timestamptz":"2024-01-02T03:04:05.000006Z"}`, string(data))
+	})
+}
@@ -0,0 +1,136 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strings"
+
+	"github.com/calumari/jwalk"
+	"github.com/go-json-experiment/json"
+
+	"github.com/calumari/poutine"
+	"github.com/calumari/poutine/database"
+	"github.com/calumari/poutine/database/internal/sqldb"
+)
+
+type Driver struct {
+	db     *sql.DB
+	schema string
+	sql    sqldb.DB
+}
+
+var (
+	_ poutine.Registrar      = (*Driver)(nil)
+	_ poutine.Encoder        = (*Driver)(nil)
+	_ database.Driver        = (*Driver)(nil)
+	_ database.Transactional = (*Driver)(nil)
+)
+
+// NewDriver returns a driver for the tables of the given schema. Tables are
+// expected to exist already (e.g. created by migrations); Seed only inserts
+// rows.
+func NewDriver(db *sql.DB, schema string) *Driver {
+	return &Driver{
+		db:     db,
+		schema: schema,
+		sql:    sqldb.DB{DB: db, Dialect: dialect{schema: schema}},
+	}
+}
+
+// Seed inserts each fixture collection as rows of the table with the same
+// name, in the transaction bound to ctx or in a transaction of its own.
+func (d *Driver) Seed(ctx context.Context, root jwalk.Document) (jwalk.Document, error) {
+	if err := d.sql.Seed(ctx, root); err != nil {
+		return nil, err
+	}
+	return root, nil
+}
+
+// Snapshot reads every base table of the schema, ordering rows by primary key
+// or, for tables without one, by ctid.
+func (d *Driver) Snapshot(ctx context.Context) (jwalk.Document, error) {
+	names, err := d.tableNames(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return d.sql.Snapshot(ctx, names)
+}
+
+func (d *Driver) Teardown(ctx context.Context) error {
+	names, err := d.tableNames(ctx)
+	if err != nil {
+		return err
+	}
+	if len(names) == 0 {
+		return nil
+	}
+	qualified := make([]string, 0, len(names))
+	for _, name := range names {
+		qualified = append(qualified, d.sql.Dialect.Table(name))
+	}
+	query := "TRUNCATE TABLE " + strings.Join(qualified, ", ") + " RESTART IDENTITY CASCADE"
+	if _, err := d.db.ExecContext(ctx, query); err != nil {
+		return fmt.Errorf("truncate tables: %w", err)
+	}
+	return nil
+}
+
+// RegisterTypes implements poutine.Registrar allowing automatic directive
+// registration.
+func (d *Driver) RegisterTypes(reg *jwalk.Registry) error {
+	for _, dir := range []*jwalk.Directive{UUIDDirective, TimestamptzDirective} {
+		if err := reg.Register(dir); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Marshalers implements poutine.Encoder, encoding UUID and timestamp column
+// values as $uuid and $timestamptz directives.
+func (d *Driver) Marshalers() *json.Marshalers {
+	return Marshalers
+}
+
+// Begin implements database.Transactional. It starts a transaction and
+// returns a context bound to it; Seed and Snapshot use the transaction of
+// their context, and code under test can find it with TxFromContext.
+func (d *Driver) Begin(ctx context.Context) (context.Context, error) {
+	return d.sql.Begin(ctx)
+}
+
+// Rollback implements database.Transactional, rolling back the transaction
+// bound to ctx.
+func (d *Driver) Rollback(ctx context.Context) error {
+	return sqldb.Rollback(ctx)
+}
+
+// TxFromContext returns the transaction bound to ctx by Begin.
+func TxFromContext(ctx context.Context) (*sql.Tx, bool) {
+	return sqldb.TxFromContext(ctx)
+}
+
+// tableNames lists the base tables of the driver schema in ascending order.
+func (d *Driver) tableNames(ctx context.Context) ([]string, error) {
+	rows, err := d.sql.Querier(ctx).QueryContext(ctx, `SELECT table_name FROM information_schema.tables
+		WHERE table_schema = $1 AND table_type = 'BASE TABLE'
+		ORDER BY table_name`, d.schema)
+	if err != nil {
+		return nil, fmt.Errorf("list table names: %w", err)
+	}
+	defer rows.Close()
+
+	var names []string
+	for rows.Next() {
+		var name string
+		if err := rows.Scan(&name); err != nil {
+			return nil, fmt.Errorf("scan table name: %w", err)
+		}
+		names = append(names, name)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("list table names: %w", err)
+	}
+	return names, nil
+}
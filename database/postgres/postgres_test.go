@@ -0,0 +1,264 @@
+package postgres_test
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"testing"
+
+	"github.com/calumari/jwalk"
+	"github.com/google/uuid"
+	_ "github.com/jackc/pgx/v5/stdlib"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/stretchr/testify/suite"
+	"github.com/testcontainers/testcontainers-go"
+	"github.com/testcontainers/testcontainers-go/wait"
+
+	"github.com/calumari/poutine/database"
+	"github.com/calumari/poutine/database/drivertest"
+	"github.com/calumari/poutine/database/postgres"
+)
+
+type PostgresSuite struct {
+	suite.Suite
+	postgresContainer testcontainers.Container
+	db                *sql.DB
+}
+
+func (s *PostgresSuite) SetupSuite() {
+	t := s.T()
+
+	postgresContainer, err := testcontainers.GenericContainer(t.Context(), testcontainers.GenericContainerRequest{
+		ContainerRequest: testcontainers.ContainerRequest{
+			Image:        "postgres:16",
+			ExposedPorts: []string{"5432/tcp"},
+			Env: map[string]string{
+				"POSTGRES_USER":     "poutine",
+				"POSTGRES_PASSWORD": "poutine",
+				"POSTGRES_DB":       "poutine",
+			},
+			WaitingFor: wait.ForAll(
+				wait.ForLog("database system is ready to accept connections").WithOccurrence(2),
+				wait.ForListeningPort("5432/tcp"),
+			),
+		},
+		Started: true,
+	})
+	require.NoError(t, err)
+
+	host, err := postgresContainer.Host(t.Context())
+	require.NoError(t, err)
+	port, err := postgresContainer.MappedPort(t.Context(), "5432/tcp")
+	require.NoError(t, err)
+	dsn := fmt.Sprintf("postgres://poutine:poutine@%s:%s/poutine?sslmode=disable", host, port.Port())
+	s.postgresContainer = postgresContainer
+
+	db, err := sql.Open("pgx", dsn)
+	require.NoError(t, err)
+	err = db.PingContext(t.Context())
+	require.NoError(t, err)
+	s.db = db
+}
+
+func (s *PostgresSuite) TearDownSuite() {
+	_ = s.db.Close()
+	_ = s.postgresContainer.Terminate(context.Background())
+}
+
+func TestPostgresSuite(t *testing.T) {
+	suite.Run(t, new(PostgresSuite))
+}
+
+// helper to create a new driver with a unique schema per subtest
+func (s *PostgresSuite) newDriver(t *testing.T) (*postgres.Driver, string) {
+	t.Helper()
+	schema := fmt.Sprintf("pgdt_%s", uuid.NewString()[:8])
+	_, err := s.db.ExecContext(t.Context(), fmt.Sprintf(`
+		CREATE SCHEMA %[1]s;
+		CREATE TABLE %[1]s.users (id uuid PRIMARY KEY, name text NOT NULL, meta jsonb);
+		CREATE TABLE %[1]s.posts (id serial PRIMARY KEY, title text NOT NULL, created_at timestamptz);
+	`, schema))
+	require.NoError(t, err)
+	t.Cleanup(func() {
+		_, _ = s.db.ExecContext(context.Background(), "DROP SCHEMA "+schema+" CASCADE")
+	})
+	return postgres.NewDriver(s.db, schema), schema
+}
+
+func (s *PostgresSuite) TestDriver_Seed() {
+	s.Run("invalid document returns error", func() {
+		t := s.T()
+		driver, _ := s.newDriver(t)
+		root := jwalk.Document{
+			{Key: "users", Value: "not an array"},
+		}
+		_, err := driver.Seed(t.Context(), root)
+		require.Error(t, err)
+	})
+
+	s.Run("inserts rows", func() {
+		t := s.T()
+		driver, schema := s.newDriver(t)
+		id := uuid.New()
+
+		root := jwalk.Document{
+			{Key: "users", Value: jwalk.Array{
+				jwalk.Document{{Key: "id", Value: id}, {Key: "name", Value: "Alice"}, {Key: "meta", Value: jwalk.Document{{Key: "admin", Value: true}}}},
+			}},
+		}
+
+		seeded, err := driver.Seed(t.Context(), root)
+		require.NoError(t, err)
+		assert.Equal(t, root, seeded)
+
+		var name string
+		var admin bool
+		err = s.db.QueryRowContext(t.Context(), "SELECT name, (meta->>'admin')::bool FROM "+schema+".users WHERE id = $1", id).Scan(&name, &admin)
+		require.NoError(t, err)
+		assert.Equal(t, "Alice", name)
+		assert.True(t, admin)
+	})
+
+	s.Run("failing row rolls back whole seed", func() {
+		t := s.T()
+		driver, schema := s.newDriver(t)
+
+		root := jwalk.Document{
+			{Key: "posts", Value: jwalk.Array{
+				jwalk.Document{{Key: "title", Value: "Hello"}},
+				jwalk.Document{{Key: "missing", Value: "column"}},
+			}},
+		}
+		_, err := driver.Seed(t.Context(), root)
+		require.Error(t, err)
+
+		var count int
+		err = s.db.QueryRowContext(t.Context(), "SELECT count(*) FROM "+schema+".posts").Scan(&count)
+		require.NoError(t, err)
+		assert.Zero(t, count)
+	})
+}
+
+func (s *PostgresSuite) TestDriver_Snapshot() {
+	s.Run("reads every table in schema", func() {
+		t := s.T()
+		driver, schema := s.newDriver(t)
+		id := uuid.New()
+
+		_, err := s.db.ExecContext(t.Context(), "INSERT INTO "+schema+".users (id, name, meta) VALUES ($1, 'Alice', '{\"admin\":true}')", id)
+		require.NoError(t, err)
+
+		got, err := driver.Snapshot(t.Context())
+		require.NoError(t, err)
+
+		want := jwalk.Document{
+			{Key: "posts", Value: jwalk.Array{}},
+			{Key: "users", Value: jwalk.Array{
+				jwalk.Document{{Key: "id", Value: id}, {Key: "name", Value: "Alice"}, {Key: "meta", Value: jwalk.Document{{Key: "admin", Value: true}}}},
+			}},
+		}
+		assert.Equal(t, want, got)
+	})
+
+	s.Run("orders rows by primary key", func() {
+		t := s.T()
+		driver, schema := s.newDriver(t)
+
+		_, err := s.db.ExecContext(t.Context(), "INSERT INTO "+schema+".posts (title) VALUES ('First'), ('Second')")
+		require.NoError(t, err)
+		// The update writes a new tuple after the second row.
+		_, err = s.db.ExecContext(t.Context(), "UPDATE "+schema+".posts SET title = 'Edited' WHERE id = 1")
+		require.NoError(t, err)
+
+		got, err := driver.Snapshot(t.Context())
+		require.NoError(t, err)
+
+		assert.Equal(t, jwalk.Array{
+			jwalk.Document{{Key: "id", Value: int64(1)}, {Key: "title", Value: "Edited"}, {Key: "created_at", Value: nil}},
+			jwalk.Document{{Key: "id", Value: int64(2)}, {Key: "title", Value: "Second"}, {Key: "created_at", Value: nil}},
+		}, got[0].Value)
+	})
+}
+
+func (s *PostgresSuite) TestDriver_Teardown() {
+	s.Run("teardown truncates tables", func() {
+		t := s.T()
+		driver, schema := s.newDriver(t)
+
+		_, err := s.db.ExecContext(t.Context(), "INSERT INTO "+schema+".posts (title) VALUES ('Hello')")
+		require.NoError(t, err)
+
+		err = driver.Teardown(t.Context())
+		require.NoError(t, err)
+
+		got, err := driver.Snapshot(t.Context())
+		require.NoError(t, err)
+		assert.Equal(t, jwalk.Document{
+			{Key: "posts", Value: jwalk.Array{}},
+			{Key: "users", Value: jwalk.Array{}},
+		}, got)
+	})
+}
+
+func (s *PostgresSuite) TestDriver_Transactional() {
+	s.Run("rollback discards seeded and written rows", func() {
+		t := s.T()
+		driver, schema := s.newDriver(t)
+
+		ctx, err := driver.Begin(t.Context())
+		require.NoError(t, err)
+		_, err = driver.Seed(ctx, jwalk.Document{
+			{Key: "posts", Value: jwalk.Array{jwalk.Document{{Key: "title", Value: "Seeded"}}}},
+		})
+		require.NoError(t, err)
+		tx, ok := postgres.TxFromContext(ctx)
+		require.True(t, ok)
+		_, err = tx.ExecContext(ctx, "INSERT INTO "+schema+".posts (title) VALUES ('Hello')")
+		require.NoError(t, err)
+
+		got, err := driver.Snapshot(ctx)
+		require.NoError(t, err)
+		posts, _ := got[0].Value.(jwalk.Array)
+		assert.Len(t, posts, 2)
+
+		require.NoError(t, driver.Rollback(ctx))
+		var count int
+		err = s.db.QueryRowContext(t.Context(), "SELECT count(*) FROM "+schema+".posts").Scan(&count)
+		require.NoError(t, err)
+		assert.Zero(t, count)
+		assert.NoError(t, driver.Rollback(ctx), "second rollback is not an error")
+	})
+}
+
+func (s *PostgresSuite) TestDriver_RegisterTypes() {
+	s.Run("register types registers postgres directives", func() {
+		t := s.T()
+		driver, _ := s.newDriver(t)
+		reg, err := jwalk.NewRegistry()
+		require.NoError(t, err)
+
+		err = driver.RegisterTypes(reg)
+		require.NoError(t, err)
+
+		// second registration should fail (duplicate directive)
+		err = driver.RegisterTypes(reg)
+		assert.Error(t, err)
+	})
+}
+
+func (s *PostgresSuite) TestDriver_Conformance() {
+	drivertest.RunConformance(s.T(), func(t *testing.T) database.Driver {
+		schema := fmt.Sprintf("pgct_%s", uuid.NewString()[:8])
+		// SET LOCAL keeps the search path from leaking into pooled connections
+		tx, err := s.db.BeginTx(t.Context(), nil)
+		require.NoError(t, err)
+		_, err = tx.ExecContext(t.Context(), "CREATE SCHEMA "+schema+"; SET LOCAL search_path TO "+schema+";"+drivertest.SQLSchema)
+		require.NoError(t, err)
+		require.NoError(t, tx.Commit())
+		t.Cleanup(func() {
+			_, _ = s.db.ExecContext(context.Background(), "DROP SCHEMA "+schema+" CASCADE")
+		})
+		return postgres.NewDriver(s.db, schema)
+	})
+}
@@ -0,0 +1,70 @@
+package postgres
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/calumari/jwalk"
+	"github.com/go-json-experiment/json"
+	"github.com/go-json-experiment/json/jsontext"
+	"github.com/google/uuid"
+
+	"github.com/calumari/poutine/exp"
+)
+
+var (
+	UUIDDirective        = jwalk.NewDirective("uuid", unmarshalUUIDPattern)
+	TimestamptzDirective = jwalk.NewDirective("timestamptz", unmarshalTimestamptzPattern)
+)
+
+func unmarshalUUIDPattern(dec *jsontext.Decoder) (exp.Pattern[uuid.UUID], error) {
+	var raw any
+	if err := json.UnmarshalDecode(dec, &raw); err != nil {
+		return exp.Pattern[uuid.UUID]{}, err
+	}
+	switch v := raw.(type) {
+	case bool:
+		if !v {
+			return exp.Pattern[uuid.UUID]{}, fmt.Errorf("$uuid bool must be true to indicate wildcard")
+		}
+		return exp.Any(uuid.New()), nil
+	case string:
+		if ref, ok := exp.ParseRef(v, uuid.New()); ok {
+			return ref, nil
+		}
+		id, err := uuid.Parse(v)
+		if err != nil {
+			return exp.Pattern[uuid.UUID]{}, err
+		}
+		return exp.Value(id), nil
+	default:
+		return exp.Pattern[uuid.UUID]{}, fmt.Errorf("invalid $uuid payload type %T", v)
+	}
+}
+
+// unmarshalTimestamptzPattern decodes RFC 3339 timestamps. Values are
+// normalised to UTC to match what Snapshot returns.
+func unmarshalTimestamptzPattern(dec *jsontext.Decoder) (exp.Pattern[time.Time], error) {
+	var raw any
+	if err := json.UnmarshalDecode(dec, &raw); err != nil {
+		return exp.Pattern[time.Time]{}, err
+	}
+	switch v := raw.(type) {
+	case bool:
+		if !v {
+			return exp.Pattern[time.Time]{}, fmt.Errorf("$timestamptz bool must be true to indicate wildcard")
+		}
+		return exp.Any(time.Now().UTC().Truncate(time.Microsecond)), nil
+	case string:
+		if ref, ok := exp.ParseRef(v, time.Now().UTC().Truncate(time.Microsecond)); ok {
+			return ref, nil
+		}
+		t, err := time.Parse(time.RFC3339Nano, v)
+		if err != nil {
+			return exp.Pattern[time.Time]{}, err
+		}
+		return exp.Value(t.UTC()), nil
+	default:
+		return exp.Pattern[time.Time]{}, fmt.Errorf("invalid $timestamptz payload type %T", v)
+	}
+}
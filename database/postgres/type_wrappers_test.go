@@ -0,0 +1,76 @@
+package postgres
+
+import (
+	"testing"
+	"time"
+
+	"github.com/calumari/jwalk"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/calumari/poutine/exp"
+)
+
+func decodeWith(t *testing.T, data string) (jwalk.Document, error) {
+	t.Helper()
+	reg, err := jwalk.NewRegistry(jwalk.WithDirective(UUIDDirective), jwalk.WithDirective(TimestamptzDirective))
+	require.NoError(t, err)
+	var doc jwalk.Document
+	err = reg.Unmarshal([]byte(data), &doc)
+	return doc, err
+}
+
+func Test_unmarshalUUIDPattern(t *testing.T) {
+	t.Run("true returns wildcard", func(t *testing.T) {
+		doc, err := decodeWith(t, `{"id":{"$uuid":true}}`)
+		require.NoError(t, err)
+		p := doc[0].Value.(exp.Pattern[uuid.UUID])
+		assert.True(t, p.IsWildcard())
+	})
+
+	t.Run("string returns explicit value", func(t *testing.T) {
+		id := uuid.New()
+		doc, err := decodeWith(t, `{"id":{"$uuid":"`+id.String()+`"}}`)
+		require.NoError(t, err)
+		assert.Equal(t, exp.Value(id), doc[0].Value)
+	})
+
+	t.Run("reference returns named pattern", func(t *testing.T) {
+		doc, err := decodeWith(t, `{"id":{"$uuid":"@alice"}}`)
+		require.NoError(t, err)
+		name, ok := doc[0].Value.(exp.Pattern[uuid.UUID]).RefName()
+		assert.True(t, ok)
+		assert.Equal(t, "alice", name)
+	})
+
+	t.Run("false returns error", func(t *testing.T) {
+		_, err := decodeWith(t, `{"id":{"$uuid":false}}`)
+		assert.Error(t, err)
+	})
+
+	t.Run("invalid string returns error", func(t *testing.T) {
+		_, err := decodeWith(t, `{"id":{"$uuid":"nope"}}`)
+		assert.Error(t, err)
+	})
+}
+
+func Test_unmarshalTimestamptzPattern(t *testing.T) {
+	t.Run("true returns wildcard", func(t *testing.T) {
+		doc, err := decodeWith(t, `{"at":{"$timestamptz":true}}`)
+		require.NoError(t, err)
+		p := doc[0].Value.(exp.Pattern[time.Time])
+		assert.True(t, p.IsWildcard())
+	})
+
+	t.Run("string returns utc value", func(t *testing.T) {
+		doc, err := decodeWith(t, `{"at":{"$timestamptz":"2024-01-02T05:04:05+02:00"}}`)
+		require.NoError(t, err)
+		assert.Equal(t, exp.Value(time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)), doc[0].Value)
+	})
+
+	t.Run("number returns error", func(t *testing.T) {
+		_, err := decodeWith(t, `{"at":{"$timestamptz":1}}`)
+		assert.Error(t, err)
+	})
+}
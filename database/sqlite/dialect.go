@@ -0,0 +1,46 @@
+package sqlite
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/calumari/poutine/database/internal/sqldb"
+)
+
+// dialect uses unqualified table names and ? placeholders. SQLite returns
+// declared column types as written, so JSON columns are matched without
+// regard to case.
+type dialect struct{}
+
+var _ sqldb.Dialect = dialect{}
+
+func (dialect) Table(name string) string {
+	return sqldb.QuoteIdent(name)
+}
+
+func (dialect) Placeholder(int) string {
+	return "?"
+}
+
+// OrderBy orders rows by the primary key of the table, or by rowid when it has
+// none.
+func (dialect) OrderBy(ctx context.Context, q sqldb.Querier, name string) ([]string, error) {
+	cols, err := sqldb.Columns(ctx, q, "SELECT name FROM pragma_table_info(?) WHERE pk > 0 ORDER BY pk", name)
+	if err != nil {
+		return nil, fmt.Errorf("primary key: %w", err)
+	}
+	if len(cols) == 0 {
+		return []string{"rowid"}, nil
+	}
+	return cols, nil
+}
+
+// Value decodes columns declared as JSON into jwalk documents/arrays so nested
+// fixture values round-trip. Other values are stored as scanned.
+func (dialect) Value(typeName string, v any) (any, error) {
+	if !strings.EqualFold(typeName, "JSON") {
+		return v, nil
+	}
+	return sqldb.DecodeJSON(v)
+}
@@ -0,0 +1,54 @@
+package sqlite
+
+import (
+	"testing"
+
+	"github.com/calumari/jwalk"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_dialect(t *testing.T) {
+	t.Run("table is quoted", func(t *testing.T) {
+		assert.Equal(t, `"we""ird"`, dialect{}.Table(`we"ird`))
+	})
+
+	t.Run("placeholders are positional", func(t *testing.T) {
+		assert.Equal(t, "?", dialect{}.Placeholder(2))
+	})
+}
+
+func Test_dialect_Value(t *testing.T) {
+	t.Run("json column returns jwalk document with sorted keys", func(t *testing.T) {
+		got, err := dialect{}.Value("JSON", `{"b":[1,{"c":true}],"a":"x"}`)
+		require.NoError(t, err)
+		want := jwalk.Document{
+			{Key: "a", Value: "x"},
+			{Key: "b", Value: jwalk.Array{1.0, jwalk.Document{{Key: "c", Value: true}}}},
+		}
+		assert.Equal(t, want, got)
+	})
+
+	t.Run("json column type is case insensitive", func(t *testing.T) {
+		got, err := dialect{}.Value("json", []byte(`[1]`))
+		require.NoError(t, err)
+		assert.Equal(t, jwalk.Array{1.0}, got)
+	})
+
+	t.Run("invalid json returns error", func(t *testing.T) {
+		_, err := dialect{}.Value("JSON", "{")
+		assert.Error(t, err)
+	})
+
+	t.Run("null json column returns nil", func(t *testing.T) {
+		got, err := dialect{}.Value("JSON", nil)
+		require.NoError(t, err)
+		assert.Nil(t, got)
+	})
+
+	t.Run("other column returns value itself", func(t *testing.T) {
+		got, err := dialect{}.Value("TEXT", `{"a":1}`)
+		require.NoError(t, err)
+		assert.Equal(t, `{"a":1}`, got)
+	})
+}
@@ -0,0 +1,109 @@
+package sqlite_test
+
+import (
+	"context"
+	"database/sql"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"github.com/stretchr/testify/suite"
+	_ "modernc.org/sqlite"
+
+	"github.com/calumari/poutine"
+	"github.com/calumari/poutine/database/sqlite"
+	"github.com/calumari/poutine/testine"
+)
+
+type RepositorySuite struct {
+	suite.Suite
+	db         *sql.DB
+	repository *Repository
+	poutine    *poutine.Poutine
+	testine    *testine.T
+}
+
+func (s *RepositorySuite) SetupSubTest() {
+	t := s.T()
+
+	db, err := sql.Open("sqlite", filepath.Join(t.TempDir(), "example.db"))
+	require.NoError(t, err)
+	_, err = db.ExecContext(t.Context(), `CREATE TABLE pets (id INTEGER PRIMARY KEY AUTOINCREMENT, name TEXT NOT NULL, type TEXT NOT NULL)`)
+	require.NoError(t, err)
+	s.db = db
+
+	s.poutine = poutine.New(sqlite.NewDriver(db))
+	pt, err := testine.New(s.poutine)
+	require.NoError(t, err)
+	s.testine = pt
+
+	s.repository = NewRepository(db)
+}
+
+func (s *RepositorySuite) TearDownSubTest() {
+	err := s.poutine.Teardown(context.Background())
+	require.NoError(s.T(), err)
+	require.NoError(s.T(), s.db.Close())
+}
+
+func (s *RepositorySuite) TestCreatePet() {
+	s.Run("create pet adds pet", func() {
+		t := s.T()
+		err := s.repository.Create(t.Context(), &Pet{Name: "Luna", Type: "cat"})
+		require.NoError(t, err)
+
+		s.testine.Assert(t, s.testine.LoadJSON(t, "test_data/pets_after_create.json"))
+	})
+}
+
+func (s *RepositorySuite) TestDeletePet() {
+	s.Run("delete non-existing pet keeps snapshot unchanged", func() {
+		t := s.T()
+		snapshot := s.testine.Seed(t, s.testine.LoadJSON(t, "test_data/pets_seed.json"))
+
+		err := s.repository.Delete(t.Context(), "NonExistent")
+		require.NoError(t, err)
+
+		snapshot.Assert(t)
+	})
+
+	s.Run("delete existing pet removes pet", func() {
+		t := s.T()
+		_ = s.testine.Seed(t, s.testine.LoadJSON(t, "test_data/pets_seed.json"))
+
+		err := s.repository.Delete(t.Context(), "Max")
+		require.NoError(t, err)
+
+		s.testine.Assert(t, s.testine.LoadJSON(t, "test_data/pets_after_delete.json"))
+	})
+}
+
+func TestRepositorySuite(t *testing.T) {
+	suite.Run(t, new(RepositorySuite))
+}
+
+// example repository and model
+
+type Pet struct {
+	ID   int64
+	Name string
+	Type string
+}
+
+type Repository struct {
+	db *sql.DB
+}
+
+func NewRepository(db *sql.DB) *Repository {
+	return &Repository{db: db}
+}
+
+func (s *Repository) Create(ctx context.Context, pet *Pet) error {
+	_, err := s.db.ExecContext(ctx, "INSERT INTO pets (name, type) VALUES (?, ?)", pet.Name, pet.Type)
+	return err
+}
+
+func (s *Repository) Delete(ctx context.Context, name string) error {
+	_, err := s.db.ExecContext(ctx, "DELETE FROM pets WHERE name = ?", name)
+	return err
+}
@@ -0,0 +1,129 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+
+	"github.com/calumari/jwalk"
+
+	"github.com/calumari/poutine/database"
+	"github.com/calumari/poutine/database/internal/sqldb"
+)
+
+type Driver struct {
+	db  *sql.DB
+	sql sqldb.DB
+}
+
+var (
+	_ database.Driver        = (*Driver)(nil)
+	_ database.Transactional = (*Driver)(nil)
+)
+
+// NewDriver returns a driver for the tables of db. Tables are expected to
+// exist already; Seed only inserts rows.
+func NewDriver(db *sql.DB) *Driver {
+	return &Driver{
+		db:  db,
+		sql: sqldb.DB{DB: db, Dialect: dialect{}},
+	}
+}
+
+// Seed inserts each fixture collection into the table of the same name. All
+// rows are written in one transaction, the one bound to ctx if there is one.
+func (d *Driver) Seed(ctx context.Context, root jwalk.Document) (jwalk.Document, error) {
+	if err := d.sql.Seed(ctx, root); err != nil {
+		return nil, err
+	}
+	return root, nil
+}
+
+// Snapshot reads every user table, ordering rows by primary key or, for
+// tables without one, by rowid.
+func (d *Driver) Snapshot(ctx context.Context) (jwalk.Document, error) {
+	names, err := d.tableNames(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return d.sql.Snapshot(ctx, names)
+}
+
+// Teardown deletes every row from every table, keeping the schema, and resets
+// AUTOINCREMENT counters.
+func (d *Driver) Teardown(ctx context.Context) error {
+	names, err := d.tableNames(ctx)
+	if err != nil {
+		return err
+	}
+
+	tx, err := d.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	for _, name := range names {
+		if _, err := tx.ExecContext(ctx, "DELETE FROM "+sqldb.QuoteIdent(name)); err != nil {
+			return fmt.Errorf("delete from table %q: %w", name, err)
+		}
+	}
+	var seq int
+	err = tx.QueryRowContext(ctx, "SELECT count(*) FROM sqlite_master WHERE type = 'table' AND name = 'sqlite_sequence'").Scan(&seq)
+	if err != nil {
+		return fmt.Errorf("find sqlite_sequence: %w", err)
+	}
+	if seq > 0 {
+		if _, err := tx.ExecContext(ctx, "DELETE FROM sqlite_sequence"); err != nil {
+			return fmt.Errorf("reset sqlite_sequence: %w", err)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("commit transaction: %w", err)
+	}
+	return nil
+}
+
+// Begin implements database.Transactional, binding a new transaction to the
+// returned context for Seed, Snapshot and TxFromContext. SQLite allows a
+// single writer, so other connections wait on it until it is rolled back.
+func (d *Driver) Begin(ctx context.Context) (context.Context, error) {
+	return d.sql.Begin(ctx)
+}
+
+// Rollback implements database.Transactional. The transaction of ctx may
+// already be done.
+func (d *Driver) Rollback(ctx context.Context) error {
+	return sqldb.Rollback(ctx)
+}
+
+// TxFromContext returns the transaction Begin bound to ctx, for the code under
+// test to run its statements in.
+func TxFromContext(ctx context.Context) (*sql.Tx, bool) {
+	return sqldb.TxFromContext(ctx)
+}
+
+// tableNames lists user tables in ascending order.
+func (d *Driver) tableNames(ctx context.Context) ([]string, error) {
+	rows, err := d.sql.Querier(ctx).QueryContext(ctx, `SELECT name FROM sqlite_master
+		WHERE type = 'table' AND name NOT LIKE 'sqlite\_%' ESCAPE '\'
+		ORDER BY name`)
+	if err != nil {
+		return nil, fmt.Errorf("list table names: %w", err)
+	}
+	defer rows.Close()
+
+	var names []string
+	for rows.Next() {
+		var name string
+		if err := rows.Scan(&name); err != nil {
+			return nil, fmt.Errorf("scan table name: %w", err)
+		}
+		names = append(names, name)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("list table names: %w", err)
+	}
+	return names, nil
+}
@@ -0,0 +1,221 @@
+package sqlite_test
+
+import (
+	"database/sql"
+	"path/filepath"
+	"testing"
+
+	"github.com/calumari/jwalk"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	_ "modernc.org/sqlite"
+
+	"github.com/calumari/poutine/database"
+	"github.com/calumari/poutine/database/drivertest"
+	"github.com/calumari/poutine/database/sqlite"
+)
+
+const schema = `
+CREATE TABLE users (id INTEGER PRIMARY KEY AUTOINCREMENT, name TEXT NOT NULL, meta JSON);
+CREATE TABLE posts (id TEXT PRIMARY KEY, title TEXT NOT NULL);
+`
+
+// helper to create a new driver backed by a fresh database file
+func newDriver(t *testing.T) (*sqlite.Driver, *sql.DB) {
+	t.Helper()
+	db, err := sql.Open("sqlite", filepath.Join(t.TempDir(), "test.db"))
+	require.NoError(t, err)
+	t.Cleanup(func() { db.Close() })
+	_, err = db.ExecContext(t.Context(), schema)
+	require.NoError(t, err)
+	return sqlite.NewDriver(db), db
+}
+
+func TestDriver_Seed(t *testing.T) {
+	t.Run("invalid document returns error", func(t *testing.T) {
+		driver, _ := newDriver(t)
+		root := jwalk.Document{
+			{Key: "users", Value: "not an array"},
+		}
+		_, err := driver.Seed(t.Context(), root)
+		require.Error(t, err)
+	})
+
+	t.Run("inserts rows", func(t *testing.T) {
+		driver, db := newDriver(t)
+		root := jwalk.Document{
+			{Key: "users", Value: jwalk.Array{
+				jwalk.Document{{Key: "name", Value: "Alice"}, {Key: "meta", Value: jwalk.Document{{Key: "admin", Value: true}}}},
+				jwalk.Document{{Key: "name", Value: "Bob"}},
+			}},
+		}
+
+		seeded, err := driver.Seed(t.Context(), root)
+		require.NoError(t, err)
+		assert.Equal(t, root, seeded)
+
+		var meta string
+		err = db.QueryRowContext(t.Context(), "SELECT meta FROM users WHERE name = 'Alice'").Scan(&meta)
+		require.NoError(t, err)
+		assert.Equal(t, `{"admin":true}`, meta)
+	})
+
+	t.Run("failing row rolls back whole seed", func(t *testing.T) {
+		driver, db := newDriver(t)
+		root := jwalk.Document{
+			{Key: "posts", Value: jwalk.Array{
+				jwalk.Document{{Key: "id", Value: "p1"}, {Key: "title", Value: "Hello"}},
+				jwalk.Document{{Key: "id", Value: "p1"}, {Key: "title", Value: "Duplicate"}},
+			}},
+		}
+		_, err := driver.Seed(t.Context(), root)
+		require.Error(t, err)
+
+		var count int
+		err = db.QueryRowContext(t.Context(), "SELECT count(*) FROM posts").Scan(&count)
+		require.NoError(t, err)
+		assert.Zero(t, count)
+	})
+}
+
+func TestDriver_Snapshot(t *testing.T) {
+	t.Run("reads every table", func(t *testing.T) {
+		driver, db := newDriver(t)
+		_, err := db.ExecContext(t.Context(), `INSERT INTO users (name, meta) VALUES ('Alice', '{"admin":true}')`)
+		require.NoError(t, err)
+
+		got, err := driver.Snapshot(t.Context())
+		require.NoError(t, err)
+
+		want := jwalk.Document{
+			{Key: "posts", Value: jwalk.Array{}},
+			{Key: "users", Value: jwalk.Array{
+				jwalk.Document{{Key: "id", Value: int64(1)}, {Key: "name", Value: "Alice"}, {Key: "meta", Value: jwalk.Document{{Key: "admin", Value: true}}}},
+			}},
+		}
+		assert.Equal(t, want, got)
+	})
+
+	t.Run("orders rows by primary key", func(t *testing.T) {
+		driver, db := newDriver(t)
+		_, err := db.ExecContext(t.Context(), `INSERT INTO posts (id, title) VALUES ('b', 'Second'), ('a', 'First')`)
+		require.NoError(t, err)
+		_, err = db.ExecContext(t.Context(), `UPDATE posts SET title = 'Edited' WHERE id = 'b'`)
+		require.NoError(t, err)
+
+		got, err := driver.Snapshot(t.Context())
+		require.NoError(t, err)
+
+		assert.Equal(t, jwalk.Array{
+			jwalk.Document{{Key: "id", Value: "a"}, {Key: "title", Value: "First"}},
+			jwalk.Document{{Key: "id", Value: "b"}, {Key: "title", Value: "Edited"}},
+		}, got[0].Value)
+	})
+
+	t.Run("orders rows by rowid without primary key", func(t *testing.T) {
+		driver, db := newDriver(t)
+		_, err := db.ExecContext(t.Context(), `CREATE TABLE tags (name TEXT NOT NULL)`)
+		require.NoError(t, err)
+		_, err = db.ExecContext(t.Context(), `INSERT INTO tags (name) VALUES ('b'), ('a')`)
+		require.NoError(t, err)
+		_, err = db.ExecContext(t.Context(), `UPDATE tags SET name = 'c' WHERE name = 'b'`)
+		require.NoError(t, err)
+
+		got, err := driver.Snapshot(t.Context())
+		require.NoError(t, err)
+
+		assert.Equal(t, jwalk.Entry{Key: "tags", Value: jwalk.Array{
+			jwalk.Document{{Key: "name", Value: "c"}},
+			jwalk.Document{{Key: "name", Value: "a"}},
+		}}, got[1])
+	})
+}
+
+func TestDriver_Teardown(t *testing.T) {
+	t.Run("teardown deletes rows and resets autoincrement", func(t *testing.T) {
+		driver, db := newDriver(t)
+		_, err := db.ExecContext(t.Context(), `INSERT INTO users (name) VALUES ('Alice')`)
+		require.NoError(t, err)
+
+		err = driver.Teardown(t.Context())
+		require.NoError(t, err)
+
+		got, err := driver.Snapshot(t.Context())
+		require.NoError(t, err)
+		assert.Equal(t, jwalk.Document{
+			{Key: "posts", Value: jwalk.Array{}},
+			{Key: "users", Value: jwalk.Array{}},
+		}, got)
+
+		_, err = db.ExecContext(t.Context(), `INSERT INTO users (name) VALUES ('Bob')`)
+		require.NoError(t, err)
+		var id int
+		err = db.QueryRowContext(t.Context(), "SELECT id FROM users").Scan(&id)
+		require.NoError(t, err)
+		assert.Equal(t, 1, id)
+	})
+
+	t.Run("teardown on empty database succeeds", func(t *testing.T) {
+		driver, _ := newDriver(t)
+		err := driver.Teardown(t.Context())
+		require.NoError(t, err)
+	})
+}
+
+func TestDriver_Transactional(t *testing.T) {
+	t.Run("rollback discards seeded and written rows", func(t *testing.T) {
+		driver, db := newDriver(t)
+		_, err := db.ExecContext(t.Context(), "INSERT INTO posts (id, title) VALUES ('p0', 'Kept')")
+		require.NoError(t, err)
+
+		ctx, err := driver.Begin(t.Context())
+		require.NoError(t, err)
+		_, err = driver.Seed(ctx, jwalk.Document{
+			{Key: "users", Value: jwalk.Array{jwalk.Document{{Key: "name", Value: "Alice"}}}},
+		})
+		require.NoError(t, err)
+		tx, ok := sqlite.TxFromContext(ctx)
+		require.True(t, ok)
+		_, err = tx.ExecContext(ctx, "INSERT INTO posts (id, title) VALUES ('p1', 'Hello')")
+		require.NoError(t, err)
+
+		got, err := driver.Snapshot(ctx)
+		require.NoError(t, err)
+		assert.Equal(t, jwalk.Document{
+			{Key: "posts", Value: jwalk.Array{
+				jwalk.Document{{Key: "id", Value: "p0"}, {Key: "title", Value: "Kept"}},
+				jwalk.Document{{Key: "id", Value: "p1"}, {Key: "title", Value: "Hello"}},
+			}},
+			{Key: "users", Value: jwalk.Array{
+				jwalk.Document{{Key: "id", Value: int64(1)}, {Key: "name", Value: "Alice"}, {Key: "meta", Value: nil}},
+			}},
+		}, got)
+
+		require.NoError(t, driver.Rollback(ctx))
+		got, err = driver.Snapshot(t.Context())
+		require.NoError(t, err)
+		assert.Equal(t, jwalk.Document{
+			{Key: "posts", Value: jwalk.Array{
+				jwalk.Document{{Key: "id", Value: "p0"}, {Key: "title", Value: "Kept"}},
+			}},
+			{Key: "users", Value: jwalk.Array{}},
+		}, got)
+		assert.NoError(t, driver.Rollback(ctx), "second rollback is not an error")
+	})
+
+	t.Run("rollback without transaction returns error", func(t *testing.T) {
+		driver, _ := newDriver(t)
+		assert.Error(t, driver.Rollback(t.Context()))
+	})
+}
+
+func TestConformance(t *testing.T) {
+	drivertest.RunConformance(t, func(t *testing.T) database.Driver {
+		db, err := sql.Open("sqlite", filepath.Join(t.TempDir(), "conformance.db"))
+		require.NoError(t, err)
+		t.Cleanup(func() { db.Close() })
+		_, err = db.ExecContext(t.Context(), drivertest.SQLSchema)
+		require.NoError(t, err)
+		return sqlite.NewDriver(db)
+	})
+}
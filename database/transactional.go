@@ -0,0 +1,16 @@
+package database
+
+import "context"
+
+// Transactional is implemented by drivers that can isolate a test in a
+// transaction rolled back at cleanup, which is much cheaper than tearing the
+// database down.
+type Transactional interface {
+	// Begin starts a transaction and returns a context bound to it. Seed and
+	// Snapshot, and any code under test that honours the context, read and
+	// write inside the transaction.
+	Begin(ctx context.Context) (context.Context, error)
+	// Rollback discards the transaction bound to ctx. Rolling back a
+	// transaction that has already ended is not an error.
+	Rollback(ctx context.Context) error
+}
@@ -0,0 +1,152 @@
+package exp
+
+import (
+	"fmt"
+	"maps"
+	"strings"
+	"sync"
+)
+
+// Bindings holds the values of named references shared by the patterns of a
+// test. Values captured while comparing are kept pending until the comparison
+// succeeds and Commit is called, so a failed or speculative comparison never
+// leaks captures into later ones.
+type Bindings struct {
+	mu      sync.Mutex
+	values  map[string]any
+	pending map[string]any
+}
+
+func NewBindings() *Bindings {
+	return &Bindings{
+		values:  make(map[string]any),
+		pending: make(map[string]any),
+	}
+}
+
+// Get returns the committed value bound to name.
+func (b *Bindings) Get(name string) (any, bool) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	v, ok := b.values[name]
+	return v, ok
+}
+
+// Set binds name to v, replacing any previous value.
+func (b *Bindings) Set(name string, v any) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.values[name] = v
+}
+
+// Map returns a copy of the committed bindings.
+func (b *Bindings) Map() map[string]any {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return maps.Clone(b.values)
+}
+
+// Begin discards pending captures before a new comparison.
+func (b *Bindings) Begin() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	clear(b.pending)
+}
+
+// Rollback discards the pending captures of a failed comparison.
+func (b *Bindings) Rollback() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	clear(b.pending)
+}
+
+// Commit binds every pending capture.
+func (b *Bindings) Commit() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	maps.Copy(b.values, b.pending)
+	clear(b.pending)
+}
+
+// resolve returns the value bound to name, binding placeholder first if name
+// is unbound.
+func (b *Bindings) resolve(name string, placeholder any) any {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if v, ok := b.values[name]; ok {
+		return v
+	}
+	b.values[name] = placeholder
+	return placeholder
+}
+
+// lookup returns the value captured by the current comparison, falling back
+// to committed values unless pendingOnly is set.
+func (b *Bindings) lookup(name string, pendingOnly bool) (any, bool) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if v, ok := b.pending[name]; ok {
+		return v, true
+	}
+	if pendingOnly {
+		return nil, false
+	}
+	v, ok := b.values[name]
+	return v, ok
+}
+
+func (b *Bindings) capture(name string, v any) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.pending[name] = v
+}
+
+// Bindable is implemented by values holding named references. Bind returns a
+// copy whose references resolve through b.
+type Bindable interface {
+	Bind(b *Bindings) any
+}
+
+// reference is the named part of a Pattern created by Ref or Capture.
+type reference struct {
+	name     string
+	capture  bool // rebinds on every comparison instead of reusing a value
+	bindings *Bindings
+}
+
+func (r *reference) lookup() (any, bool) {
+	if r.bindings == nil {
+		return nil, false
+	}
+	return r.bindings.lookup(r.name, r.capture)
+}
+
+func (r *reference) String() string {
+	prefix := "@"
+	if r.capture {
+		prefix = "?"
+	}
+	if v, ok := r.lookup(); ok {
+		return fmt.Sprintf("%s%s (%v)", prefix, r.name, v)
+	}
+	return prefix + r.name
+}
+
+// ParseRef parses "@name" into a Ref and "?name" into a Capture pattern. It
+// reports false for any other string so directive parsers can fall back to
+// their regular payload.
+func ParseRef[T any](s string, placeholder T) (Pattern[T], bool) {
+	if len(s) < 2 || (s[0] != '@' && s[0] != '?') {
+		return Pattern[T]{}, false
+	}
+	name := s[1:]
+	if strings.ContainsFunc(name, func(r rune) bool {
+		return !(r == '_' || r == '-' || r == '.' || r >= '0' && r <= '9' || r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z')
+	}) {
+		return Pattern[T]{}, false
+	}
+	if s[0] == '?' {
+		return Capture(name, placeholder), true
+	}
+	return Ref(name, placeholder), true
+}
@@ -0,0 +1,172 @@
+package exp
+
+import (
+	"testing"
+
+	"github.com/calumari/jwalk"
+	"github.com/calumari/testequals"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBindings(t *testing.T) {
+	t.Run("set and get committed value", func(t *testing.T) {
+		b := NewBindings()
+		b.Set("a", 1)
+		got, ok := b.Get("a")
+		assert.True(t, ok)
+		assert.Equal(t, 1, got)
+		assert.Equal(t, map[string]any{"a": 1}, b.Map())
+	})
+
+	t.Run("pending captures commit", func(t *testing.T) {
+		b := NewBindings()
+		b.capture("a", 1)
+		_, ok := b.Get("a")
+		assert.False(t, ok)
+		b.Commit()
+		got, ok := b.Get("a")
+		assert.True(t, ok)
+		assert.Equal(t, 1, got)
+	})
+
+	t.Run("begin discards pending captures", func(t *testing.T) {
+		b := NewBindings()
+		b.capture("a", 1)
+		b.Begin()
+		b.Commit()
+		assert.Empty(t, b.Map())
+	})
+
+	t.Run("rollback discards pending captures", func(t *testing.T) {
+		b := NewBindings()
+		b.Set("a", 1)
+		b.capture("a", 2)
+		b.Rollback()
+		got, ok := b.lookup("a", false)
+		assert.True(t, ok)
+		assert.Equal(t, 1, got)
+	})
+
+	t.Run("map returns copy", func(t *testing.T) {
+		b := NewBindings()
+		b.Set("a", 1)
+		m := b.Map()
+		m["a"] = 2
+		got, _ := b.Get("a")
+		assert.Equal(t, 1, got)
+	})
+}
+
+func TestParseRef(t *testing.T) {
+	tests := []struct {
+		in      string
+		name    string
+		capture bool
+		ok      bool
+	}{
+		{"@alice", "alice", false, true},
+		{"@order.id", "order.id", false, true},
+		{"?order.id", "order.id", true, true},
+		{"@", "", false, false},
+		{"alice", "", false, false},
+		{"@not a name", "", false, false},
+		{"?what?", "", false, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.in, func(t *testing.T) {
+			p, ok := ParseRef(tt.in, 0)
+			require.Equal(t, tt.ok, ok)
+			if !ok {
+				return
+			}
+			name, _ := p.RefName()
+			assert.Equal(t, tt.name, name)
+			assert.Equal(t, tt.capture, p.ref.capture)
+			assert.True(t, p.IsWildcard())
+		})
+	}
+}
+
+func TestRef(t *testing.T) {
+	tester := testequals.New()
+
+	t.Run("unbound ref acts as wildcard", func(t *testing.T) {
+		p := Ref("a", "placeholder")
+		assert.Equal(t, "placeholder", p.UnwrapValue())
+		assert.NoError(t, tester.Test(p, "x"))
+		assert.Error(t, tester.Test(p, true))
+	})
+
+	t.Run("seeding binds placeholder once", func(t *testing.T) {
+		b := NewBindings()
+		first := Ref("a", "p1").Bind(b).(Pattern[string])
+		second := Ref("a", "p2").Bind(b).(Pattern[string])
+		assert.Equal(t, "p1", first.UnwrapValue())
+		assert.Equal(t, "p1", second.UnwrapValue())
+	})
+
+	t.Run("bound ref requires bound value", func(t *testing.T) {
+		b := NewBindings()
+		b.Set("a", "x")
+		p := Ref("a", "").Bind(b)
+		assert.NoError(t, tester.Test(p, "x"))
+		assert.Error(t, tester.Test(p, "y"))
+	})
+
+	t.Run("unbound ref captures first match within comparison", func(t *testing.T) {
+		b := NewBindings()
+		p := Ref("a", "").Bind(b)
+		b.Begin()
+		assert.NoError(t, tester.Test(jwalk.Array{p, p}, jwalk.Array{"x", "x"}))
+		b.Commit()
+		got, ok := b.Get("a")
+		assert.True(t, ok)
+		assert.Equal(t, "x", got)
+
+		b.Begin()
+		assert.Error(t, tester.Test(jwalk.Array{p}, jwalk.Array{"y"}))
+	})
+
+	t.Run("unbound ref rejects differing occurrences", func(t *testing.T) {
+		b := NewBindings()
+		p := Ref("a", "").Bind(b)
+		b.Begin()
+		assert.Error(t, tester.Test(jwalk.Array{p, p}, jwalk.Array{"x", "y"}))
+	})
+
+	t.Run("string shows name and bound value", func(t *testing.T) {
+		b := NewBindings()
+		assert.Equal(t, "@a", Ref("a", "").String())
+		b.Set("a", "x")
+		assert.Equal(t, "@a (x)", Ref("a", "").Bind(b).(Pattern[string]).String())
+	})
+}
+
+func TestCapture(t *testing.T) {
+	tester := testequals.New()
+
+	t.Run("capture rebinds on every comparison", func(t *testing.T) {
+		b := NewBindings()
+		b.Set("a", "old")
+		p := Capture("a", "").Bind(b)
+		b.Begin()
+		assert.NoError(t, tester.Test(p, "new"))
+		b.Commit()
+		got, _ := b.Get("a")
+		assert.Equal(t, "new", got)
+	})
+
+	t.Run("capture requires consistent occurrences", func(t *testing.T) {
+		b := NewBindings()
+		p := Capture("a", "").Bind(b)
+		b.Begin()
+		assert.Error(t, tester.Test(jwalk.Array{p, p}, jwalk.Array{"x", "y"}))
+	})
+
+	t.Run("unbound pattern does not capture", func(t *testing.T) {
+		p := Capture("a", "")
+		assert.NoError(t, tester.Test(p, "x"))
+		assert.Equal(t, "?a", p.String())
+	})
+}
@@ -0,0 +1,420 @@
+package exp
+
+import (
+	"bytes"
+	"cmp"
+	"errors"
+	"fmt"
+	"math"
+	"reflect"
+	"runtime"
+	"strings"
+	"sync"
+	"time"
+	"weak"
+
+	"github.com/calumari/jwalk"
+	"github.com/calumari/testequals"
+	"github.com/go-json-experiment/json"
+	"github.com/go-json-experiment/json/jsontext"
+)
+
+// Matcher directives describe the shape or range of a value instead of the
+// value itself:
+//
+//	{"$regex": "^[a-z]+@example\\.com$"}         // string matching a pattern
+//	{"$gt": 0}, {"$lte": "2024-01-01T00:00:00Z"} // ordered comparison
+//	{"$in": ["draft", "published"]}              // equal to one of the values
+//	{"$len": 3}, {"$len": {"$gte": 1}}           // length of a string, array or object
+//	{"$contains": "admin"}                       // array element or substring
+//	{"$type": "string"}                          // type name
+//	{"$not": {"$in": ["deleted"]}}               // negation
+//	{"$anyOf": [{"$type": "null"}, {"$gt": 0}]}  // any alternative
+//
+// Payloads may nest other directives. Matchers only apply when comparing;
+// they are not meant to be seeded.
+//
+// $regex, $in, $not and $anyOf decode the testequals rules test.regex,
+// test.in, test.not and test.or. The comparisons and $len are our own: they
+// also order strings and times, and measure strings and objects.
+var (
+	RegexDirective    = builtinMatcher("regex", "test.regex")
+	GtDirective       = jwalk.NewDirective("gt", unmarshalCompare(">"))
+	GteDirective      = jwalk.NewDirective("gte", unmarshalCompare(">="))
+	LtDirective       = jwalk.NewDirective("lt", unmarshalCompare("<"))
+	LteDirective      = jwalk.NewDirective("lte", unmarshalCompare("<="))
+	InDirective       = builtinMatcher("in", "test.in")
+	LenDirective      = jwalk.NewDirective("len", unmarshalLen)
+	ContainsDirective = jwalk.NewDirective("contains", unmarshalContains)
+	TypeDirective     = jwalk.NewDirective("type", unmarshalType)
+	NotDirective      = builtinMatcher("not", "test.not")
+	AnyOfDirective    = builtinMatcher("anyOf", "test.or")
+)
+
+// builtinRules decodes the testequals rules reused by builtinMatcher.
+var builtinRules = func() *jwalk.Registry {
+	reg, err := jwalk.NewRegistry(
+		jwalk.WithDirective(testequals.TestMatchStringDirective),
+		jwalk.WithDirective(testequals.TestInDirective),
+		jwalk.WithDirective(testequals.TestNotDirective),
+		jwalk.WithDirective(testequals.TestOrDirective),
+	)
+	if err != nil {
+		panic(err)
+	}
+	return reg
+}()
+
+// Matchers lists every matcher directive, including the presence directives.
+var Matchers = []*jwalk.Directive{
+	RegexDirective,
+	GtDirective,
+	GteDirective,
+	LtDirective,
+	LteDirective,
+	InDirective,
+	LenDirective,
+	ContainsDirective,
+	TypeDirective,
+	NotDirective,
+	AnyOfDirective,
+	AbsentDirective,
+	NullDirective,
+}
+
+// matcherRegistries holds the registries RegisterMatchers has filled. Keys
+// are weak so that registries can still be collected.
+var (
+	matcherRegistriesMu sync.Mutex
+	matcherRegistries   = map[weak.Pointer[jwalk.Registry]]struct{}{}
+)
+
+// RegisterMatchers registers every matcher directive with reg. Registering
+// them again with the same registry does nothing, so a registry can be shared
+// by several helpers, but a directive of another package already registered
+// under a matcher name is an error.
+func RegisterMatchers(reg *jwalk.Registry) error {
+	matcherRegistriesMu.Lock()
+	defer matcherRegistriesMu.Unlock()
+	key := weak.Make(reg)
+	if _, ok := matcherRegistries[key]; ok {
+		return nil
+	}
+	for _, d := range Matchers {
+		if err := reg.Register(d); err != nil {
+			return err
+		}
+	}
+	matcherRegistries[key] = struct{}{}
+	runtime.AddCleanup(reg, func(key weak.Pointer[jwalk.Registry]) {
+		matcherRegistriesMu.Lock()
+		delete(matcherRegistries, key)
+		matcherRegistriesMu.Unlock()
+	}, key)
+	return nil
+}
+
+var (
+	_ testequals.Rule = builtinRule{}
+	_ testequals.Rule = compareMatcher{}
+	_ testequals.Rule = lenMatcher{}
+	_ testequals.Rule = containsMatcher{}
+	_ testequals.Rule = typeMatcher{}
+)
+
+// Gt matches values ordered after v. Numbers compare numerically, strings
+// lexically and times chronologically; an RFC 3339 string may be compared
+// with a time.
+func Gt(v any) testequals.Rule { return compareMatcher{op: ">", want: v} }
+
+// Gte matches values ordered after or equal to v. See Gt.
+func Gte(v any) testequals.Rule { return compareMatcher{op: ">=", want: v} }
+
+// Lt matches values ordered before v. See Gt.
+func Lt(v any) testequals.Rule { return compareMatcher{op: "<", want: v} }
+
+// Lte matches values ordered before or equal to v. See Gt.
+func Lte(v any) testequals.Rule { return compareMatcher{op: "<=", want: v} }
+
+// Len matches strings, arrays and objects whose length satisfies want, an
+// integer or a matcher such as Gte(1).
+func Len(want any) testequals.Rule { return lenMatcher{want} }
+
+// Contains matches arrays holding an element that matches want, and strings
+// holding want as a substring.
+func Contains(want any) testequals.Rule { return containsMatcher{want} }
+
+// Type matches values of the named type: "string", "number", "integer",
+// "bool", "array", "object", "null", "time", or a Go type name as printed by
+// %T, such as "bson.ObjectID". "integer" also accepts floats with an
+// integral value, as JSON numbers decode to float64.
+func Type(name string) testequals.Rule { return typeMatcher{name} }
+
+// builtinMatcher returns a directive decoding its payload as the testequals
+// directive target.
+func builtinMatcher(name, target string) *jwalk.Directive {
+	return jwalk.NewDirective(name, func(dec *jsontext.Decoder) (testequals.Rule, error) {
+		raw, err := dec.ReadValue()
+		if err != nil {
+			return nil, fmt.Errorf("invalid $%s payload: %w", name, err)
+		}
+		raw = raw.Clone()
+		// decode with the options of dec so payloads may nest directives
+		v, err := builtinRules.InvokeDirective(target, jsontext.NewDecoder(bytes.NewReader(raw), dec.Options()))
+		if err != nil {
+			return nil, fmt.Errorf("invalid $%s payload: %w", name, err)
+		}
+		_ = raw.Compact()
+		return builtinRule{Rule: v.(testequals.Rule), text: "$" + name + " " + string(raw)}, nil
+	})
+}
+
+// builtinRule is a testequals rule described as the directive it was decoded
+// from, for diffs.
+type builtinRule struct {
+	testequals.Rule
+	text string
+}
+
+func (r builtinRule) String() string { return r.text }
+
+type compareMatcher struct {
+	op   string
+	want any
+}
+
+func (m compareMatcher) Test(_ *testequals.RuleContext, actual any) error {
+	c, err := compare(actual, unwrap(m.want))
+	if err != nil {
+		return fmt.Errorf("%s: %w", m, err)
+	}
+	var ok bool
+	switch m.op {
+	case ">":
+		ok = c > 0
+	case ">=":
+		ok = c >= 0
+	case "<":
+		ok = c < 0
+	case "<=":
+		ok = c <= 0
+	}
+	if !ok {
+		return fmt.Errorf("expected %s, got %v", m, actual)
+	}
+	return nil
+}
+
+func (m compareMatcher) String() string { return fmt.Sprintf("%s %v", m.op, unwrap(m.want)) }
+
+type lenMatcher struct{ want any }
+
+func (m lenMatcher) Test(rc *testequals.RuleContext, actual any) error {
+	n, ok := length(actual)
+	if !ok {
+		return fmt.Errorf("$len expects string, array or object, got %T", actual)
+	}
+	if err := rc.Test(m.want, n); err != nil {
+		return fmt.Errorf("expected %s, got length %d", m, n)
+	}
+	return nil
+}
+
+func (m lenMatcher) String() string { return fmt.Sprintf("$len %v", m.want) }
+
+type containsMatcher struct{ want any }
+
+func (m containsMatcher) Test(rc *testequals.RuleContext, actual any) error {
+	if s, ok := actual.(string); ok {
+		sub, ok := m.want.(string)
+		if !ok {
+			return fmt.Errorf("$contains on a string expects a string, got %T", m.want)
+		}
+		if !strings.Contains(s, sub) {
+			return fmt.Errorf("%q does not contain %q", s, sub)
+		}
+		return nil
+	}
+	rv := reflect.ValueOf(actual)
+	if rv.Kind() != reflect.Slice && rv.Kind() != reflect.Array {
+		return fmt.Errorf("$contains expects string or array, got %T", actual)
+	}
+	for i := range rv.Len() {
+		if rc.Test(m.want, rv.Index(i).Interface()) == nil {
+			return nil
+		}
+	}
+	return fmt.Errorf("no element matches %v", m.want)
+}
+
+func (m containsMatcher) String() string { return fmt.Sprintf("$contains %v", m.want) }
+
+type typeMatcher struct{ name string }
+
+func (m typeMatcher) Test(_ *testequals.RuleContext, actual any) error {
+	got := typeName(actual)
+	if got != m.name && !(m.name == "number" && got == "integer") && fmt.Sprintf("%T", actual) != m.name {
+		return fmt.Errorf("expected type %s, got %s (%T)", m.name, got, actual)
+	}
+	return nil
+}
+
+func (m typeMatcher) String() string { return "$type " + m.name }
+
+func unmarshalCompare(op string) func(*jsontext.Decoder) (testequals.Rule, error) {
+	return func(dec *jsontext.Decoder) (testequals.Rule, error) {
+		var v any
+		if err := json.UnmarshalDecode(dec, &v); err != nil {
+			return nil, fmt.Errorf("invalid %s payload: %w", op, err)
+		}
+		switch unwrap(v).(type) {
+		case nil, bool, jwalk.Document, jwalk.Array:
+			return nil, fmt.Errorf("%s expects a number, string or time, got %T", op, v)
+		}
+		return compareMatcher{op: op, want: v}, nil
+	}
+}
+
+func unmarshalLen(dec *jsontext.Decoder) (testequals.Rule, error) {
+	var v any
+	if err := json.UnmarshalDecode(dec, &v); err != nil {
+		return nil, fmt.Errorf("invalid $len payload: %w", err)
+	}
+	switch want := v.(type) {
+	case float64:
+		if want < 0 || want != float64(int(want)) {
+			return nil, fmt.Errorf("$len expects a non-negative integer, got %v", want)
+		}
+		return Len(int(want)), nil
+	case testequals.Rule:
+		return Len(want), nil
+	default:
+		return nil, fmt.Errorf("$len expects an integer or matcher, got %T", v)
+	}
+}
+
+func unmarshalContains(dec *jsontext.Decoder) (testequals.Rule, error) {
+	var v any
+	if err := json.UnmarshalDecode(dec, &v); err != nil {
+		return nil, fmt.Errorf("invalid $contains payload: %w", err)
+	}
+	return Contains(v), nil
+}
+
+func unmarshalType(dec *jsontext.Decoder) (testequals.Rule, error) {
+	var name string
+	if err := json.UnmarshalDecode(dec, &name); err != nil {
+		return nil, fmt.Errorf("invalid $type payload: %w", err)
+	}
+	if name == "" {
+		return nil, errors.New("$type expects a type name")
+	}
+	return Type(name), nil
+}
+
+// unwrap returns the value a pattern seeds, or v itself.
+func unwrap(v any) any {
+	if u, ok := v.(interface{ UnwrapValue() any }); ok {
+		return u.UnwrapValue()
+	}
+	return v
+}
+
+// compare orders a against b. Numbers of any kind compare numerically,
+// strings lexically and times chronologically. Types exposing a Time method,
+// such as bson.DateTime, count as times when compared with a time or an
+// RFC 3339 string.
+func compare(a, b any) (int, error) {
+	if ta, ok := asTime(a); ok {
+		if tb, ok := asTime(b); ok {
+			return ta.Compare(tb), nil
+		}
+	}
+	if fa, ok := asFloat(a); ok {
+		if fb, ok := asFloat(b); ok {
+			return cmp.Compare(fa, fb), nil
+		}
+	}
+	if sa, ok := a.(string); ok {
+		if sb, ok := b.(string); ok {
+			return strings.Compare(sa, sb), nil
+		}
+	}
+	return 0, fmt.Errorf("cannot compare %T with %T", a, b)
+}
+
+func asTime(v any) (time.Time, bool) {
+	switch t := v.(type) {
+	case time.Time:
+		return t, true
+	case interface{ Time() time.Time }:
+		return t.Time(), true
+	case string:
+		parsed, err := time.Parse(time.RFC3339Nano, t)
+		return parsed, err == nil
+	default:
+		return time.Time{}, false
+	}
+}
+
+func asFloat(v any) (float64, bool) {
+	rv := reflect.ValueOf(v)
+	switch rv.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return float64(rv.Int()), true
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return float64(rv.Uint()), true
+	case reflect.Float32, reflect.Float64:
+		return rv.Float(), true
+	default:
+		return 0, false
+	}
+}
+
+func length(v any) (int, bool) {
+	switch val := v.(type) {
+	case string:
+		return len([]rune(val)), true
+	case jwalk.Document:
+		return len(val), true
+	}
+	rv := reflect.ValueOf(v)
+	switch rv.Kind() {
+	case reflect.Slice, reflect.Array, reflect.Map:
+		return rv.Len(), true
+	default:
+		return 0, false
+	}
+}
+
+// typeName classifies v for $type. Numbers holding an integral value are
+// reported as "integer", which "number" also accepts.
+func typeName(v any) string {
+	switch v.(type) {
+	case nil:
+		return "null"
+	case string:
+		return "string"
+	case bool:
+		return "bool"
+	case jwalk.Document, map[string]any:
+		return "object"
+	case jwalk.Array, []any:
+		return "array"
+	case time.Time, interface{ Time() time.Time }:
+		return "time"
+	}
+	rv := reflect.ValueOf(v)
+	switch rv.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return "integer"
+	case reflect.Float32, reflect.Float64:
+		if f := rv.Float(); f == math.Trunc(f) {
+			return "integer"
+		}
+		return "number"
+	default:
+		return fmt.Sprintf("%T", v)
+	}
+}
@@ -0,0 +1,141 @@
+package exp
+
+import (
+	"testing"
+	"time"
+
+	"github.com/calumari/jwalk"
+	"github.com/calumari/testequals"
+	"github.com/go-json-experiment/json/jsontext"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func decodeMatcher(t *testing.T, in string) (any, error) {
+	t.Helper()
+	reg, err := jwalk.NewRegistry()
+	require.NoError(t, err)
+	require.NoError(t, RegisterMatchers(reg))
+	var v any
+	err = reg.Unmarshal([]byte(in), &v)
+	return v, err
+}
+
+type dateTime int64
+
+func (d dateTime) Time() time.Time { return time.UnixMilli(int64(d)) }
+
+func TestMatchers(t *testing.T) {
+	tester := testequals.New()
+	jan := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name   string
+		in     string
+		actual any
+		ok     bool
+	}{
+		{"regex matches string", `{"$regex": "^a.c$"}`, "abc", true},
+		{"regex rejects string", `{"$regex": "^a.c$"}`, "abd", false},
+		{"regex rejects non string", `{"$regex": "1"}`, 1.0, false},
+		{"gt matches larger number", `{"$gt": 1}`, int32(2), true},
+		{"gt rejects equal number", `{"$gt": 1}`, 1.0, false},
+		{"gte matches equal number", `{"$gte": 1}`, int64(1), true},
+		{"lt matches smaller string", `{"$lt": "b"}`, "a", true},
+		{"lte rejects later time", `{"$lte": "2024-01-01T00:00:00Z"}`, jan.Add(time.Second), false},
+		{"gt matches later time method", `{"$gt": "2023-12-31T00:00:00Z"}`, dateTime(jan.UnixMilli()), true},
+		{"gt rejects incomparable type", `{"$gt": 1}`, "2", false},
+		{"in matches listed value", `{"$in": ["draft", "published"]}`, "draft", true},
+		{"in rejects other value", `{"$in": ["draft", "published"]}`, "deleted", false},
+		{"in matches nested matcher", `{"$in": [{"$regex": "^d"}]}`, "deleted", true},
+		{"len matches string length", `{"$len": 3}`, "héé", true},
+		{"len matches array length", `{"$len": 2}`, jwalk.Array{1.0, 2.0}, true},
+		{"len matches nested matcher", `{"$len": {"$gte": 1}}`, jwalk.Document{{Key: "a", Value: 1.0}}, true},
+		{"len rejects length", `{"$len": {"$gte": 1}}`, jwalk.Array{}, false},
+		{"len rejects number", `{"$len": 1}`, 1.0, false},
+		{"contains matches substring", `{"$contains": "mi"}`, "admin", true},
+		{"contains matches element", `{"$contains": "admin"}`, jwalk.Array{"user", "admin"}, true},
+		{"contains matches document element subset", `{"$contains": {"role": "admin"}}`, jwalk.Array{jwalk.Document{{Key: "role", Value: "admin"}, {Key: "id", Value: 1.0}}}, true},
+		{"contains rejects missing element", `{"$contains": "admin"}`, jwalk.Array{"user"}, false},
+		{"type matches string", `{"$type": "string"}`, "x", true},
+		{"type matches integer as number", `{"$type": "number"}`, 3.0, true},
+		{"type matches integral float as integer", `{"$type": "integer"}`, 3.0, true},
+		{"type rejects fraction as integer", `{"$type": "integer"}`, 3.5, false},
+		{"type matches null", `{"$type": "null"}`, nil, true},
+		{"type matches go type name", `{"$type": "time.Time"}`, jan, true},
+		{"type rejects other type", `{"$type": "array"}`, jwalk.Document{}, false},
+		{"not matches other value", `{"$not": {"$in": ["deleted"]}}`, "active", true},
+		{"not rejects matching value", `{"$not": "deleted"}`, "deleted", false},
+		{"anyOf matches alternative", `{"$anyOf": [{"$type": "null"}, {"$gt": 0}]}`, 5.0, true},
+		{"anyOf rejects every alternative", `{"$anyOf": [{"$type": "null"}, {"$gt": 0}]}`, -1.0, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m, err := decodeMatcher(t, tt.in)
+			require.NoError(t, err)
+			err = tester.Test(m, tt.actual)
+			if tt.ok {
+				assert.NoError(t, err)
+			} else {
+				assert.Error(t, err)
+			}
+		})
+	}
+
+	t.Run("matcher inside document succeeds", func(t *testing.T) {
+		doc, err := decodeMatcher(t, `{"email": {"$regex": "@example\\.com$"}, "age": {"$gte": 18}}`)
+		require.NoError(t, err)
+		assert.NoError(t, tester.Test(doc, jwalk.Document{
+			{Key: "email", Value: "a@example.com"},
+			{Key: "age", Value: 30.0},
+		}))
+	})
+}
+
+func TestMatchers_invalid(t *testing.T) {
+	tests := []struct {
+		name string
+		in   string
+	}{
+		{"regex with invalid pattern returns error", `{"$regex": "("}`},
+		{"gt with object returns error", `{"$gt": {"a": 1}}`},
+		{"in with empty array returns error", `{"$in": []}`},
+		{"in with non array returns error", `{"$in": "a"}`},
+		{"len with negative number returns error", `{"$len": -1}`},
+		{"len with fraction returns error", `{"$len": 1.5}`},
+		{"type with empty name returns error", `{"$type": ""}`},
+		{"anyOf with empty array returns error", `{"$anyOf": []}`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := decodeMatcher(t, tt.in)
+			assert.Error(t, err)
+		})
+	}
+}
+
+func TestRegisterMatchers(t *testing.T) {
+	t.Run("registering twice succeeds", func(t *testing.T) {
+		reg, err := jwalk.NewRegistry()
+		require.NoError(t, err)
+		require.NoError(t, RegisterMatchers(reg))
+		assert.NoError(t, RegisterMatchers(reg))
+	})
+
+	t.Run("conflicting directive returns error", func(t *testing.T) {
+		reg, err := jwalk.NewRegistry()
+		require.NoError(t, err)
+		require.NoError(t, reg.Register(jwalk.NewDirective("regex", func(*jsontext.Decoder) (string, error) {
+			return "", nil
+		})))
+		assert.ErrorContains(t, RegisterMatchers(reg), `directive "regex" already registered`)
+	})
+}
+
+func TestMatchers_String(t *testing.T) {
+	assert.Equal(t, "> 1", Gt(1).(interface{ String() string }).String())
+	v, err := decodeMatcher(t, `{"$in": [ "a", {"$regex": "^b"} ]}`)
+	require.NoError(t, err)
+	assert.Equal(t, `$in ["a",{"$regex":"^b"}]`, v.(interface{ String() string }).String())
+	assert.Equal(t, "$type string", Type("string").(interface{ String() string }).String())
+}
@@ -16,13 +16,21 @@ import (
 // In all present states a stable value is stored (placeholder for Any, user
 // value for Value). This allows patterns like {"$oid": true} (Any with
 // placeholder) or {"$oid":"abc"} (explicit Value).
+//
+// A wildcard may also be named (see Ref and Capture) so that every occurrence
+// of the name resolves to the same value once the pattern is bound to a
+// Bindings table.
 type Pattern[T any] struct {
 	value    T
 	present  bool // true for any or explicit
 	explicit bool // true only for explicit
+	ref      *reference
 }
 
-var _ testequals.Rule = Pattern[any]{}
+var (
+	_ testequals.Rule = Pattern[any]{}
+	_ Bindable        = Pattern[any]{}
+)
 
 func Absent[T any]() Pattern[T] {
 	return Pattern[T]{}
@@ -36,6 +44,41 @@ func Value[T any](v T) Pattern[T] {
 	return Pattern[T]{value: v, present: true, explicit: true}
 }
 
+// Ref returns a wildcard named name. Seeding a bound Ref stores the value
+// already bound to name, binding placeholder first if there is none. During
+// comparison a bound Ref must equal its value; an unbound Ref captures the
+// first value it matches.
+func Ref[T any](name string, placeholder T) Pattern[T] {
+	return Pattern[T]{value: placeholder, present: true, ref: &reference{name: name}}
+}
+
+// Capture returns a wildcard named name that captures the value it matches in
+// every comparison, replacing any earlier binding once the comparison
+// succeeds. Later occurrences within the same comparison must equal the
+// captured value.
+func Capture[T any](name string, placeholder T) Pattern[T] {
+	return Pattern[T]{value: placeholder, present: true, ref: &reference{name: name, capture: true}}
+}
+
+// RefName returns the name of a Ref or Capture pattern.
+func (p Pattern[T]) RefName() (string, bool) {
+	if p.ref == nil {
+		return "", false
+	}
+	return p.ref.name, true
+}
+
+// Bind implements Bindable. Patterns without a name are returned unchanged.
+func (p Pattern[T]) Bind(b *Bindings) any {
+	if p.ref == nil {
+		return p
+	}
+	ref := *p.ref
+	ref.bindings = b
+	p.ref = &ref
+	return p
+}
+
 func (p Pattern[T]) IsPresent() bool {
 	return p.present
 }
@@ -47,6 +90,9 @@ func (p Pattern[T]) IsWildcard() bool {
 }
 
 func (p Pattern[T]) UnwrapValue() any {
+	if p.ref != nil && p.ref.bindings != nil {
+		return p.ref.bindings.resolve(p.ref.name, p.value)
+	}
 	if p.IsPresent() {
 		return p.value
 	}
@@ -63,6 +109,9 @@ func (p Pattern[T]) String() string {
 	if !p.IsPresent() {
 		return "absent"
 	}
+	if p.ref != nil {
+		return p.ref.String()
+	}
 	if p.IsExplicit() {
 		return fmt.Sprintf("%v", p.value)
 	}
@@ -76,7 +125,23 @@ func (p Pattern[T]) Test(rc *testequals.RuleContext, actual any) error {
 	if p.IsExplicit() {
 		return rc.Test(p.value, actual)
 	}
-	// Wildcard: enforce only type compatibility, not value equality.
+	if p.ref != nil {
+		if v, ok := p.ref.lookup(); ok {
+			return rc.Test(v, actual)
+		}
+		if err := p.testType(actual); err != nil {
+			return err
+		}
+		if p.ref.bindings != nil {
+			p.ref.bindings.capture(p.ref.name, actual)
+		}
+		return nil
+	}
+	return p.testType(actual)
+}
+
+// testType enforces only type compatibility, not value equality.
+func (p Pattern[T]) testType(actual any) error {
 	if _, ok := actual.(T); !ok { // fast path
 		tv := reflect.TypeOf((*T)(nil)).Elem()
 		if actual == nil {
@@ -3,6 +3,7 @@ package exp
 import (
 	"testing"
 
+	"github.com/calumari/testequals"
 	"github.com/stretchr/testify/assert"
 )
 
@@ -27,6 +28,12 @@ func TestAbsent(t *testing.T) {
 		want := false
 		assert.Equal(t, want, got)
 	})
+
+	t.Run("absent pattern matches nil", func(t *testing.T) {
+		tester := testequals.New()
+		assert.NoError(t, tester.Test(Absent[any](), nil))
+		assert.Error(t, tester.Test(Absent[any](), "x"))
+	})
 }
 
 func TestAny(t *testing.T) {
@@ -0,0 +1,105 @@
+package exp
+
+import (
+	"fmt"
+	"reflect"
+
+	"github.com/calumari/jwalk"
+	"github.com/calumari/testequals"
+	"github.com/go-json-experiment/json"
+	"github.com/go-json-experiment/json/jsontext"
+)
+
+// Presence directives distinguish a missing field from a null one:
+//
+//	{"$absent": true}  // the field must not exist
+//	{"$absent": false} // the field must exist, with any value including null
+//	{"$null": true}    // the field must exist and be null
+//	{"$null": false}   // the field must exist and not be null
+var (
+	AbsentDirective = jwalk.NewDirective("absent", unmarshalAbsent)
+	NullDirective   = jwalk.NewDirective("null", unmarshalNull)
+)
+
+var (
+	_ testequals.Rule = nullMatcher{}
+	_ testequals.Rule = absentMatcher{}
+)
+
+// IsAbsent reports whether v expects a document field to be absent, as
+// Missing and {"$absent": true} do. The Absent pattern is not: it matches a
+// nil value.
+func IsAbsent(v any) bool {
+	_, ok := v.(absentMatcher)
+	return ok
+}
+
+// Missing requires a document field not to exist. Rules only see values that
+// exist, so Missing fails for any value: the caller must drop the field from
+// the expected document when the actual document lacks the key (see
+// IsAbsent). Missing cannot be seeded.
+func Missing() testequals.Rule { return absentMatcher{} }
+
+type absentMatcher struct{}
+
+func (absentMatcher) Test(_ *testequals.RuleContext, actual any) error {
+	return fmt.Errorf("expected field to be absent, got %v", actual)
+}
+
+func (absentMatcher) String() string {
+	return "absent"
+}
+
+// Null matches nil values, including typed nil pointers, slices and maps.
+func Null() testequals.Rule { return nullMatcher{want: true} }
+
+// NotNull matches any value other than nil.
+func NotNull() testequals.Rule { return nullMatcher{want: false} }
+
+type nullMatcher struct{ want bool }
+
+func (m nullMatcher) Test(_ *testequals.RuleContext, actual any) error {
+	if isNil(actual) != m.want {
+		return fmt.Errorf("expected %s, got %v", m, actual)
+	}
+	return nil
+}
+
+func (m nullMatcher) String() string {
+	if m.want {
+		return "null"
+	}
+	return "not null"
+}
+
+func isNil(v any) bool {
+	if v == nil {
+		return true
+	}
+	rv := reflect.ValueOf(v)
+	switch rv.Kind() {
+	case reflect.Pointer, reflect.Interface, reflect.Map, reflect.Slice, reflect.Func, reflect.Chan:
+		return rv.IsNil()
+	default:
+		return false
+	}
+}
+
+func unmarshalAbsent(dec *jsontext.Decoder) (testequals.Rule, error) {
+	var absent bool
+	if err := json.UnmarshalDecode(dec, &absent); err != nil {
+		return nil, fmt.Errorf("invalid $absent payload: %w", err)
+	}
+	if absent {
+		return absentMatcher{}, nil
+	}
+	return Any[any](nil), nil
+}
+
+func unmarshalNull(dec *jsontext.Decoder) (testequals.Rule, error) {
+	var null bool
+	if err := json.UnmarshalDecode(dec, &null); err != nil {
+		return nil, fmt.Errorf("invalid $null payload: %w", err)
+	}
+	return nullMatcher{want: null}, nil
+}
@@ -0,0 +1,68 @@
+package exp
+
+import (
+	"testing"
+
+	"github.com/calumari/jwalk"
+	"github.com/calumari/testequals"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPresenceDirectives(t *testing.T) {
+	tester := testequals.New()
+
+	t.Run("absent true decodes absent pattern", func(t *testing.T) {
+		got, err := decodeMatcher(t, `{"$absent": true}`)
+		require.NoError(t, err)
+		assert.True(t, IsAbsent(got))
+		assert.Equal(t, Missing(), got)
+		assert.Error(t, tester.Test(got, nil))
+		assert.Error(t, tester.Test(got, "x"))
+	})
+
+	t.Run("absent false requires any value", func(t *testing.T) {
+		got, err := decodeMatcher(t, `{"$absent": false}`)
+		require.NoError(t, err)
+		assert.False(t, IsAbsent(got))
+		assert.NoError(t, tester.Test(got, nil))
+		assert.NoError(t, tester.Test(got, "x"))
+	})
+
+	t.Run("null true matches nil", func(t *testing.T) {
+		got, err := decodeMatcher(t, `{"$null": true}`)
+		require.NoError(t, err)
+		assert.NoError(t, tester.Test(got, nil))
+		assert.NoError(t, tester.Test(got, (*int)(nil)))
+		assert.Error(t, tester.Test(got, 0.0))
+	})
+
+	t.Run("null false rejects nil", func(t *testing.T) {
+		got, err := decodeMatcher(t, `{"$null": false}`)
+		require.NoError(t, err)
+		assert.Error(t, tester.Test(got, nil))
+		assert.NoError(t, tester.Test(got, ""))
+	})
+
+	t.Run("null field requires key", func(t *testing.T) {
+		got, err := decodeMatcher(t, `{"deletedAt": {"$null": true}}`)
+		require.NoError(t, err)
+		assert.NoError(t, tester.Test(got, jwalk.Document{{Key: "deletedAt", Value: nil}}))
+		assert.Error(t, tester.Test(got, jwalk.Document{}))
+	})
+
+	t.Run("non boolean payload returns error", func(t *testing.T) {
+		_, err := decodeMatcher(t, `{"$absent": "yes"}`)
+		assert.Error(t, err)
+		_, err = decodeMatcher(t, `{"$null": 1}`)
+		assert.Error(t, err)
+	})
+}
+
+func TestIsAbsent(t *testing.T) {
+	assert.True(t, IsAbsent(Missing()))
+	assert.False(t, IsAbsent(Absent[int]()), "the absent pattern matches nil")
+	assert.False(t, IsAbsent(Any(0)))
+	assert.False(t, IsAbsent(nil))
+	assert.False(t, IsAbsent("x"))
+}
@@ -2,8 +2,11 @@ package poutine
 
 import (
 	"context"
+	"errors"
+	"fmt"
 
 	"github.com/calumari/jwalk"
+	"github.com/go-json-experiment/json"
 
 	"github.com/calumari/poutine/database"
 )
@@ -12,11 +15,22 @@ type Registrar interface {
 	RegisterTypes(*jwalk.Registry) error
 }
 
+// Encoder is implemented by drivers that can encode their native snapshot
+// values back into the fixture directives registered by RegisterTypes.
+type Encoder interface {
+	Marshalers() *json.Marshalers
+}
+
 type Poutine struct {
 	driver database.Driver
 }
 
-var _ Registrar = (*Poutine)(nil)
+var (
+	_ Registrar              = (*Poutine)(nil)
+	_ Encoder                = (*Poutine)(nil)
+	_ database.Transactional = (*Poutine)(nil)
+	_ database.Checkpointer  = (*Poutine)(nil)
+)
 
 func New(driver database.Driver) *Poutine {
 	return &Poutine{
@@ -42,3 +56,46 @@ func (p *Poutine) RegisterTypes(reg *jwalk.Registry) error {
 	}
 	return nil
 }
+
+func (p *Poutine) Marshalers() *json.Marshalers {
+	if e, ok := p.driver.(Encoder); ok {
+		return e.Marshalers()
+	}
+	return nil
+}
+
+// Begin implements database.Transactional. It returns an error wrapping
+// errors.ErrUnsupported when the driver is not transactional.
+func (p *Poutine) Begin(ctx context.Context) (context.Context, error) {
+	if tx, ok := p.driver.(database.Transactional); ok {
+		return tx.Begin(ctx)
+	}
+	return nil, fmt.Errorf("%T: transactions: %w", p.driver, errors.ErrUnsupported)
+}
+
+// Rollback implements database.Transactional. It returns an error wrapping
+// errors.ErrUnsupported when the driver is not transactional.
+func (p *Poutine) Rollback(ctx context.Context) error {
+	if tx, ok := p.driver.(database.Transactional); ok {
+		return tx.Rollback(ctx)
+	}
+	return fmt.Errorf("%T: transactions: %w", p.driver, errors.ErrUnsupported)
+}
+
+// Checkpoint implements database.Checkpointer. It returns an error wrapping
+// errors.ErrUnsupported when the driver cannot checkpoint.
+func (p *Poutine) Checkpoint(ctx context.Context) (string, error) {
+	if c, ok := p.driver.(database.Checkpointer); ok {
+		return c.Checkpoint(ctx)
+	}
+	return "", fmt.Errorf("%T: checkpoints: %w", p.driver, errors.ErrUnsupported)
+}
+
+// Restore implements database.Checkpointer. It returns an error wrapping
+// errors.ErrUnsupported when the driver cannot checkpoint.
+func (p *Poutine) Restore(ctx context.Context, id string) error {
+	if c, ok := p.driver.(database.Checkpointer); ok {
+		return c.Restore(ctx, id)
+	}
+	return fmt.Errorf("%T: checkpoints: %w", p.driver, errors.ErrUnsupported)
+}
@@ -2,9 +2,11 @@ package poutine
 
 import (
 	"context"
+	"errors"
 	"testing"
 
 	"github.com/calumari/jwalk"
+	"github.com/go-json-experiment/json"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
 	"github.com/stretchr/testify/require"
@@ -38,6 +40,45 @@ func (m *mockRegistrarDriver) RegisterTypes(reg *jwalk.Registry) error {
 	return args.Error(0)
 }
 
+type mockEncoderDriver struct{ mockDriver }
+
+var _ Encoder = (*mockEncoderDriver)(nil)
+
+func (m *mockEncoderDriver) Marshalers() *json.Marshalers {
+	args := m.Called()
+	m2, _ := args.Get(0).(*json.Marshalers)
+	return m2
+}
+
+type mockTransactionalDriver struct{ mockDriver }
+
+var _ database.Transactional = (*mockTransactionalDriver)(nil)
+
+func (m *mockTransactionalDriver) Begin(ctx context.Context) (context.Context, error) {
+	args := m.Called(ctx)
+	c, _ := args.Get(0).(context.Context)
+	return c, args.Error(1)
+}
+
+func (m *mockTransactionalDriver) Rollback(ctx context.Context) error {
+	args := m.Called(ctx)
+	return args.Error(0)
+}
+
+type mockCheckpointerDriver struct{ mockDriver }
+
+var _ database.Checkpointer = (*mockCheckpointerDriver)(nil)
+
+func (m *mockCheckpointerDriver) Checkpoint(ctx context.Context) (string, error) {
+	args := m.Called(ctx)
+	return args.String(0), args.Error(1)
+}
+
+func (m *mockCheckpointerDriver) Restore(ctx context.Context, id string) error {
+	args := m.Called(ctx, id)
+	return args.Error(0)
+}
+
 func docKV(k string, v any) jwalk.Document { return jwalk.Document{{Key: k, Value: v}} }
 
 func TestPoutine_Seed(t *testing.T) {
@@ -146,3 +187,64 @@ func TestPoutine_RegisterTypes(t *testing.T) {
 		md.AssertExpectations(t)
 	})
 }
+
+func TestPoutine_Marshalers(t *testing.T) {
+	t.Run("encoder driver returns driver marshalers", func(t *testing.T) {
+		md := &mockEncoderDriver{}
+		want := json.MarshalFunc(func(v bool) ([]byte, error) { return []byte(`"yes"`), nil })
+		md.On("Marshalers").Return(want).Once()
+		p := New(md)
+		assert.Same(t, want, p.Marshalers())
+		md.AssertExpectations(t)
+	})
+
+	t.Run("non-encoder driver returns nil", func(t *testing.T) {
+		p := New(&mockDriver{})
+		assert.Nil(t, p.Marshalers())
+	})
+}
+
+func TestPoutine_Transactional(t *testing.T) {
+	type txKey struct{}
+
+	t.Run("transactional driver begins and rolls back", func(t *testing.T) {
+		md := &mockTransactionalDriver{}
+		txCtx := context.WithValue(t.Context(), txKey{}, true)
+		md.On("Begin", mock.Anything).Return(txCtx, nil).Once()
+		md.On("Rollback", txCtx).Return(nil).Once()
+		p := New(md)
+		got, err := p.Begin(t.Context())
+		require.NoError(t, err)
+		assert.Equal(t, txCtx, got)
+		assert.NoError(t, p.Rollback(got))
+		md.AssertExpectations(t)
+	})
+
+	t.Run("non-transactional driver returns unsupported error", func(t *testing.T) {
+		p := New(&mockDriver{})
+		_, err := p.Begin(t.Context())
+		assert.True(t, errors.Is(err, errors.ErrUnsupported))
+		assert.True(t, errors.Is(p.Rollback(t.Context()), errors.ErrUnsupported))
+	})
+}
+
+func TestPoutine_Checkpointer(t *testing.T) {
+	t.Run("checkpointer driver checkpoints and restores", func(t *testing.T) {
+		md := &mockCheckpointerDriver{}
+		md.On("Checkpoint", mock.Anything).Return("cp1", nil).Once()
+		md.On("Restore", mock.Anything, "cp1").Return(nil).Once()
+		p := New(md)
+		id, err := p.Checkpoint(t.Context())
+		require.NoError(t, err)
+		assert.Equal(t, "cp1", id)
+		assert.NoError(t, p.Restore(t.Context(), id))
+		md.AssertExpectations(t)
+	})
+
+	t.Run("other driver returns unsupported error", func(t *testing.T) {
+		p := New(&mockDriver{})
+		_, err := p.Checkpoint(t.Context())
+		assert.True(t, errors.Is(err, errors.ErrUnsupported))
+		assert.True(t, errors.Is(p.Restore(t.Context(), "cp1"), errors.ErrUnsupported))
+	})
+}
@@ -0,0 +1,105 @@
+package testine
+
+import (
+	"strconv"
+
+	"github.com/calumari/jwalk"
+
+	"github.com/calumari/poutine/exp"
+)
+
+// absenceTester lets expected documents require a field to be missing. Rules
+// only see values that exist, so entries expecting an absent field are
+// removed before comparison wherever the actual document lacks the key; where
+// the key exists, the absent pattern itself reports the mismatch.
+type absenceTester struct {
+	Tester
+}
+
+func (t absenceTester) Test(expected, actual any) error {
+	expected, _ = stripAbsent(expected, actual)
+	return t.Tester.Test(expected, actual)
+}
+
+// stripAbsent returns expected without the absent entries satisfied by
+// actual, and whether anything was removed. Unchanged containers are returned
+// as is.
+func stripAbsent(expected, actual any) (any, bool) {
+	switch want := expected.(type) {
+	case jwalk.Document:
+		act, ok := actual.(jwalk.Document)
+		if !ok {
+			return expected, false
+		}
+		var out jwalk.Document
+		for i, e := range want {
+			av, found := lookup(act, e.Key)
+			drop := !found && exp.IsAbsent(e.Value)
+			v, changed := e.Value, false
+			if found {
+				v, changed = stripAbsent(e.Value, av)
+			}
+			if out == nil && (drop || changed) {
+				out = make(jwalk.Document, i, len(want))
+				copy(out, want[:i])
+			}
+			if out != nil && !drop {
+				out = append(out, jwalk.Entry{Key: e.Key, Value: v})
+			}
+		}
+		if out == nil {
+			return expected, false
+		}
+		return out, true
+	case jwalk.Array:
+		act, ok := actual.(jwalk.Array)
+		if !ok {
+			return expected, false
+		}
+		var out jwalk.Array
+		for i := range min(len(want), len(act)) {
+			v, changed := stripAbsent(want[i], act[i])
+			if !changed {
+				continue
+			}
+			if out == nil {
+				out = make(jwalk.Array, len(want))
+				copy(out, want)
+			}
+			out[i] = v
+		}
+		if out == nil {
+			return expected, false
+		}
+		return out, true
+	default:
+		return expected, false
+	}
+}
+
+// absentPath returns the path of the first value of v expecting an absent
+// field. Such values only make sense in expectations and cannot be seeded.
+func absentPath(v any, path string) (string, bool) {
+	if exp.IsAbsent(v) {
+		return path, true
+	}
+	switch val := v.(type) {
+	case jwalk.Document:
+		for _, e := range val {
+			p := e.Key
+			if path != "" {
+				p = path + "." + e.Key
+			}
+			if found, ok := absentPath(e.Value, p); ok {
+				return found, true
+			}
+		}
+	case jwalk.Array:
+		for i, e := range val {
+			if found, ok := absentPath(e, path+"["+strconv.Itoa(i)+"]"); ok {
+				return found, true
+			}
+		}
+	}
+	return "", false
+}
@@ -0,0 +1,102 @@
+package testine
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/calumari/jwalk"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/calumari/poutine/exp"
+)
+
+func Test_stripAbsent(t *testing.T) {
+	t.Run("drops absent entries missing from actual", func(t *testing.T) {
+		expected := jwalk.Document{{Key: "users", Value: jwalk.Array{
+			jwalk.Document{{Key: "name", Value: "Alice"}, {Key: "legacy", Value: exp.Missing()}},
+		}}}
+		actual := jwalk.Document{{Key: "users", Value: jwalk.Array{
+			jwalk.Document{{Key: "name", Value: "Alice"}},
+		}}}
+		got, changed := stripAbsent(expected, actual)
+		assert.True(t, changed)
+		assert.Equal(t, jwalk.Document{{Key: "users", Value: jwalk.Array{
+			jwalk.Document{{Key: "name", Value: "Alice"}},
+		}}}, got)
+		assert.Len(t, expected[0].Value.(jwalk.Array)[0], 2, "expected must not be modified")
+	})
+
+	t.Run("keeps absent entries present in actual", func(t *testing.T) {
+		expected := jwalk.Document{{Key: "legacy", Value: exp.Missing()}}
+		got, changed := stripAbsent(expected, jwalk.Document{{Key: "legacy", Value: 1.0}})
+		assert.False(t, changed)
+		assert.Equal(t, expected, got)
+	})
+}
+
+func TestT_Assert_absent(t *testing.T) {
+	newT := func(t *testing.T, docs ...jwalk.Document) *T {
+		pt, driver := newMemoryT(t)
+		driver.Insert("users", docs...)
+		return pt
+	}
+
+	t.Run("seeding absent field returns fatal", func(t *testing.T) {
+		pt := newT(t)
+		ft := &mockTestingT{}
+		pt.Seed(ft, loadTemp(t, pt, `{"users": [{"name": "Alice", "legacy": {"$absent": true}}]}`))
+		assert.Equal(t, "seed: users[0].legacy: $absent cannot be seeded", ft.fatal)
+		got, err := pt.poutine.Snapshot(t.Context())
+		require.NoError(t, err)
+		assert.Equal(t, jwalk.Document{{Key: "users", Value: jwalk.Array{}}}, got, "nothing is seeded")
+	})
+
+	t.Run("absent field missing succeeds", func(t *testing.T) {
+		pt := newT(t, jwalk.Document{{Key: "name", Value: "Alice"}})
+		pt.Assert(t, loadTemp(t, pt, `{"users": [{"name": "Alice", "legacy": {"$absent": true}}]}`))
+	})
+
+	t.Run("absent field present returns error", func(t *testing.T) {
+		pt := newT(t, jwalk.Document{{Key: "name", Value: "Alice"}, {Key: "legacy", Value: nil}})
+		ft := &mockTestingT{}
+		pt.Assert(ft, loadTemp(t, pt, `{"users": [{"name": "Alice", "legacy": {"$absent": true}}]}`))
+		assert.Contains(t, ft.fatal, "expected field to be absent")
+	})
+
+	t.Run("null field present succeeds", func(t *testing.T) {
+		pt := newT(t, jwalk.Document{{Key: "deletedAt", Value: nil}})
+		pt.Assert(t, loadTemp(t, pt, `{"users": [{"deletedAt": {"$null": true}}]}`))
+	})
+
+	t.Run("null field missing returns error", func(t *testing.T) {
+		pt := newT(t, jwalk.Document{{Key: "name", Value: "Alice"}})
+		ft := &mockTestingT{}
+		pt.Assert(ft, loadTemp(t, pt, `{"users": [{"deletedAt": {"$null": true}}]}`))
+		assert.Contains(t, ft.fatal, "key not found")
+	})
+
+	t.Run("absent collection missing succeeds", func(t *testing.T) {
+		pt := newT(t, jwalk.Document{{Key: "name", Value: "Alice"}})
+		pt.Assert(t, loadTemp(t, pt, `{"users": [{"name": "Alice"}], "sessions": {"$absent": true}}`))
+	})
+
+	t.Run("diff shows satisfied absent field as context", func(t *testing.T) {
+		pt := newT(t, jwalk.Document{{Key: "name", Value: "Alice"}})
+		ft := &mockTestingT{}
+		pt.Assert(ft, loadTemp(t, pt, `{"users": [{"name": "Bob", "legacy": {"$absent": true}}]}`))
+		assert.Contains(t, ft.fatal, "  users[0].legacy: (absent)")
+	})
+
+	t.Run("update mode keeps satisfied absent directive", func(t *testing.T) {
+		pt := newT(t, jwalk.Document{{Key: "name", Value: "Alice"}})
+		pt.update = true
+		path := filepath.Join(t.TempDir(), "expected.json")
+		require.NoError(t, os.WriteFile(path, []byte(`{"users": [{"name": "Bob", "legacy": {"$absent": true}}]}`), 0o644))
+		pt.Assert(t, pt.LoadJSON(t, path))
+		data, err := os.ReadFile(path)
+		require.NoError(t, err)
+		assert.JSONEq(t, `{"users": [{"name": "Alice", "legacy": {"$absent": true}}]}`, string(data))
+	})
+}
@@ -0,0 +1,72 @@
+package testine
+
+import (
+	"github.com/calumari/jwalk"
+
+	"github.com/calumari/poutine/exp"
+)
+
+// bindValue returns v with every exp.Bindable value bound to b. Documents and
+// arrays are copied only when one of their values changes.
+func bindValue(v any, b *exp.Bindings) any {
+	switch val := v.(type) {
+	case jwalk.Document:
+		var out jwalk.Document
+		for i, e := range val {
+			bound := bindValue(e.Value, b)
+			if out == nil && !same(bound, e.Value) {
+				out = make(jwalk.Document, len(val))
+				copy(out, val)
+			}
+			if out != nil {
+				out[i].Value = bound
+			}
+		}
+		if out == nil {
+			return val
+		}
+		return out
+	case jwalk.Array:
+		var out jwalk.Array
+		for i, e := range val {
+			bound := bindValue(e, b)
+			if out == nil && !same(bound, e) {
+				out = make(jwalk.Array, len(val))
+				copy(out, val)
+			}
+			if out != nil {
+				out[i] = bound
+			}
+		}
+		if out == nil {
+			return val
+		}
+		return out
+	case exp.Bindable:
+		return val.Bind(b)
+	default:
+		return v
+	}
+}
+
+// same reports whether bindValue left v unchanged. Containers are compared by
+// identity; bound values always count as changed.
+func same(bound, v any) bool {
+	switch b := bound.(type) {
+	case jwalk.Document:
+		d, ok := v.(jwalk.Document)
+		return ok && len(b) == len(d) && (len(b) == 0 || &b[0] == &d[0])
+	case jwalk.Array:
+		a, ok := v.(jwalk.Array)
+		return ok && len(b) == len(a) && (len(b) == 0 || &b[0] == &a[0])
+	case exp.Bindable:
+		return false
+	default:
+		return true
+	}
+}
+
+// bind binds the references of doc to those of t.
+func (pt *T) bind(t TestingT, doc jwalk.Document) jwalk.Document {
+	return bindValue(doc, pt.testBindings(t)).(jwalk.Document)
+}
@@ -0,0 +1,205 @@
+package testine
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/calumari/jwalk"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/calumari/poutine/database/memory"
+	"github.com/calumari/poutine/exp"
+)
+
+func TestT_references(t *testing.T) {
+	t.Run("seed resolves reference across collections and files succeeds", func(t *testing.T) {
+		dir := writeDirFiles(t, map[string]string{
+			"users.json": `{"users": [{"id": {"$id": "@alice"}, "name": "Alice"}]}`,
+			"pets.json":  `{"pets": [{"ownerId": {"$id": "@alice"}, "name": "Luna"}]}`,
+		})
+		driver := memory.NewDriver()
+		pt := newGoldenT(t, driver)
+		pt.Seed(t, pt.LoadJSON(t, dir))
+
+		users, _ := driver.Collection("users")
+		pets, _ := driver.Collection("pets")
+		id, _ := lookup(users[0].(jwalk.Document), "id")
+		ownerID, _ := lookup(pets[0].(jwalk.Document), "ownerId")
+		assert.Equal(t, "generated", id)
+		assert.Equal(t, id, ownerID)
+	})
+
+	t.Run("assert with reference to seeded value succeeds", func(t *testing.T) {
+		driver := memory.NewDriver()
+		pt := newGoldenT(t, driver)
+		snap := pt.Seed(t, jwalk.Document{
+			{Key: "users", Value: jwalk.Array{jwalk.Document{{Key: "id", Value: exp.Ref("alice", "u1")}}}},
+		})
+		snap.Assert(t)
+		pt.Assert(t, jwalk.Document{
+			{Key: "users", Value: jwalk.Array{jwalk.Document{{Key: "id", Value: exp.Ref("alice", "")}}}},
+		})
+	})
+
+	t.Run("assert with reference to differing value returns error", func(t *testing.T) {
+		driver := memory.NewDriver()
+		pt := newGoldenT(t, driver)
+		ft := &mockTestingT{}
+		pt.Seed(ft, jwalk.Document{
+			{Key: "users", Value: jwalk.Array{jwalk.Document{{Key: "id", Value: exp.Ref("alice", "u1")}}}},
+		})
+		driver.Mutate("users", func(docs jwalk.Array) jwalk.Array {
+			return jwalk.Array{jwalk.Document{{Key: "id", Value: "u2"}}}
+		})
+		pt.Assert(ft, jwalk.Document{
+			{Key: "users", Value: jwalk.Array{jwalk.Document{{Key: "id", Value: exp.Ref("alice", "")}}}},
+		})
+		assert.Contains(t, ft.fatal, "@alice (u1)")
+	})
+
+	t.Run("assert with unbound reference matches first occurrence succeeds", func(t *testing.T) {
+		driver := memory.NewDriver()
+		driver.Insert("users", jwalk.Document{{Key: "id", Value: "u1"}})
+		driver.Insert("pets", jwalk.Document{{Key: "ownerId", Value: "u1"}})
+		pt := newGoldenT(t, driver)
+		path := filepath.Join(t.TempDir(), "expected.json")
+		require.NoError(t, os.WriteFile(path, []byte(`{
+			"pets": [{"ownerId": {"$id": "@owner"}}],
+			"users": [{"id": {"$id": "@owner"}}]
+		}`), 0o644))
+		pt.Assert(t, pt.LoadJSON(t, path))
+	})
+
+	t.Run("assert with unbound reference to differing values returns error", func(t *testing.T) {
+		driver := memory.NewDriver()
+		driver.Insert("users", jwalk.Document{{Key: "id", Value: "u1"}})
+		driver.Insert("pets", jwalk.Document{{Key: "ownerId", Value: "u2"}})
+		pt := newGoldenT(t, driver)
+		ft := &mockTestingT{}
+		pt.Assert(ft, jwalk.Document{
+			{Key: "pets", Value: jwalk.Array{jwalk.Document{{Key: "ownerId", Value: exp.Ref("owner", "")}}}},
+			{Key: "users", Value: jwalk.Array{jwalk.Document{{Key: "id", Value: exp.Ref("owner", "")}}}},
+		})
+		assert.NotEmpty(t, ft.fatal)
+	})
+
+	t.Run("failed assert does not bind captured values", func(t *testing.T) {
+		driver := memory.NewDriver()
+		driver.Insert("users", jwalk.Document{{Key: "id", Value: "u1"}, {Key: "name", Value: "Alice"}})
+		pt := newGoldenT(t, driver)
+		ft := &mockTestingT{}
+		pt.Assert(ft, jwalk.Document{
+			{Key: "users", Value: jwalk.Array{jwalk.Document{{Key: "id", Value: exp.Ref("alice", "")}, {Key: "name", Value: "Bob"}}}},
+		})
+		assert.NotContains(t, pt.Bindings(ft), "alice")
+	})
+}
+
+func TestT_captures(t *testing.T) {
+	t.Run("assert returns captured values succeeds", func(t *testing.T) {
+		driver := memory.NewDriver()
+		driver.Insert("orders", jwalk.Document{{Key: "id", Value: "o1"}, {Key: "total", Value: 10.0}})
+		pt := newGoldenT(t, driver)
+		path := filepath.Join(t.TempDir(), "created.json")
+		require.NoError(t, os.WriteFile(path, []byte(`{"orders": [{"id": {"$id": "?orderId"}, "total": 10}]}`), 0o644))
+
+		got := pt.Assert(t, pt.LoadJSON(t, path))
+		assert.Equal(t, map[string]any{"orderId": "o1"}, got)
+		assert.Equal(t, got, pt.Bindings(t))
+	})
+
+	t.Run("later fixture reuses captured value succeeds", func(t *testing.T) {
+		driver := memory.NewDriver()
+		driver.Insert("orders", jwalk.Document{{Key: "id", Value: "o1"}})
+		pt := newGoldenT(t, driver)
+		dir := writeDirFiles(t, map[string]string{
+			"created.json":  `{"orders": [{"id": {"$id": "?orderId"}}]}`,
+			"payments.json": `{"payments": [{"orderId": {"$id": "@orderId"}}]}`,
+		})
+		pt.Assert(t, pt.LoadJSON(t, filepath.Join(dir, "created.json")))
+
+		pt.Seed(t, pt.LoadJSON(t, filepath.Join(dir, "payments.json")))
+		payments, _ := driver.Collection("payments")
+		orderID, _ := lookup(payments[0].(jwalk.Document), "orderId")
+		assert.Equal(t, "o1", orderID)
+	})
+
+	t.Run("capture rebinds on later assert succeeds", func(t *testing.T) {
+		driver := memory.NewDriver()
+		driver.Insert("orders", jwalk.Document{{Key: "id", Value: "o1"}})
+		pt := newGoldenT(t, driver)
+		expected := jwalk.Document{{Key: "orders", Value: jwalk.Array{jwalk.Document{{Key: "id", Value: exp.Capture("orderId", "")}}}}}
+		pt.Assert(t, expected)
+
+		driver.Mutate("orders", func(jwalk.Array) jwalk.Array {
+			return jwalk.Array{jwalk.Document{{Key: "id", Value: "o2"}}}
+		})
+		got := pt.Assert(t, expected)
+		assert.Equal(t, "o2", got["orderId"])
+	})
+
+	t.Run("subtests sharing a helper capture independently succeeds", func(t *testing.T) {
+		driver := memory.NewDriver()
+		driver.Insert("orders", jwalk.Document{{Key: "id", Value: "o1"}})
+		pt := newGoldenT(t, driver)
+		expected := jwalk.Document{{Key: "orders", Value: jwalk.Array{jwalk.Document{{Key: "id", Value: exp.Capture("orderId", "")}}}}}
+
+		t.Run("first", func(t *testing.T) {
+			assert.Equal(t, "o1", pt.Assert(t, expected)["orderId"])
+		})
+		driver.Mutate("orders", func(jwalk.Array) jwalk.Array {
+			return jwalk.Array{jwalk.Document{{Key: "id", Value: "o2"}}}
+		})
+		t.Run("second", func(t *testing.T) {
+			assert.Empty(t, pt.Bindings(t))
+			assert.Equal(t, "o2", pt.Assert(t, expected)["orderId"])
+		})
+		assert.Empty(t, pt.Bindings(t))
+	})
+
+	t.Run("completed test drops its bindings succeeds", func(t *testing.T) {
+		driver := memory.NewDriver()
+		driver.Insert("orders", jwalk.Document{{Key: "id", Value: "o1"}})
+		pt := newGoldenT(t, driver)
+		ft := &mockTestingT{}
+		pt.Assert(ft, jwalk.Document{{Key: "orders", Value: jwalk.Array{jwalk.Document{{Key: "id", Value: exp.Capture("orderId", "")}}}}})
+		require.Len(t, pt.bindings, 1)
+
+		ft.finish()
+		assert.Empty(t, pt.bindings)
+	})
+
+	t.Run("failed assert returns nil", func(t *testing.T) {
+		driver := memory.NewDriver()
+		driver.Insert("orders", jwalk.Document{{Key: "id", Value: "o1"}, {Key: "total", Value: 10.0}})
+		pt := newGoldenT(t, driver)
+		ft := &mockTestingT{}
+		got := pt.Assert(ft, jwalk.Document{
+			{Key: "orders", Value: jwalk.Array{jwalk.Document{{Key: "id", Value: exp.Capture("orderId", "")}, {Key: "total", Value: 20.0}}}},
+		})
+		assert.Nil(t, got)
+		assert.Empty(t, pt.Bindings(ft))
+	})
+}
+
+func Test_bindValue(t *testing.T) {
+	t.Run("leaves documents without references untouched", func(t *testing.T) {
+		doc := jwalk.Document{{Key: "users", Value: jwalk.Array{jwalk.Document{{Key: "name", Value: "Alice"}}}}}
+		got := bindValue(doc, exp.NewBindings()).(jwalk.Document)
+		assert.Same(t, &doc[0], &got[0])
+	})
+
+	t.Run("copies documents holding references", func(t *testing.T) {
+		b := exp.NewBindings()
+		b.Set("alice", "u1")
+		doc := jwalk.Document{{Key: "users", Value: jwalk.Array{jwalk.Document{{Key: "id", Value: exp.Ref("alice", "")}}}}}
+		got := bindValue(doc, b).(jwalk.Document)
+		assert.NotSame(t, &doc[0], &got[0])
+		id := got[0].Value.(jwalk.Array)[0].(jwalk.Document)[0].Value.(exp.Pattern[string])
+		assert.Equal(t, "u1", id.UnwrapValue())
+		original := doc[0].Value.(jwalk.Array)[0].(jwalk.Document)[0].Value.(exp.Pattern[string])
+		assert.Equal(t, "@alice", original.String())
+	})
+}
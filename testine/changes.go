@@ -0,0 +1,143 @@
+package testine
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"slices"
+
+	"github.com/calumari/jwalk"
+)
+
+// collectionChanges is the decoded entry of a collection in the changes
+// passed to Snapshot.AssertChanges:
+//
+//	"users": {
+//	  "key": "_id",
+//	  "inserted": [{"_id": 3, "name": "carol"}],
+//	  "updated": [{"_id": 1, "role": "admin"}],
+//	  "deleted": [{"_id": 2}]
+//	}
+type collectionChanges struct {
+	key      string
+	inserted jwalk.Array
+	updated  jwalk.Array
+	deleted  jwalk.Array
+}
+
+// AssertChanges asserts that the database equals the seeded state with the
+// given changes applied, and nothing else changed. changes maps collection
+// names to their inserted documents, field-level patches of updated
+// documents and deleted documents. Updated and deleted documents are matched
+// against the seeded ones by an explicit key field, _id unless the
+// collection sets "key". Like Assert, it returns the bound references.
+func (s *Snapshot) AssertChanges(t TestingT, changes jwalk.Document) map[string]any {
+	t.Helper()
+	expected, err := applyChanges(s.expected, changes)
+	if err != nil {
+		t.Fatalf("changes: %v", err)
+		return nil
+	}
+	return s.pt.Assert(t, expected)
+}
+
+// applyChanges returns a copy of seeded with changes applied. Patches are
+// deep-merged into the seeded document, so nested fields can be changed
+// without repeating their siblings; {"$absent": true} removes a field.
+func applyChanges(seeded, changes jwalk.Document) (jwalk.Document, error) {
+	out := copyDoc(seeded)
+	for _, e := range changes {
+		c, err := parseChanges(e.Value)
+		if err != nil {
+			return nil, fmt.Errorf("collection %q: %w", e.Key, err)
+		}
+		i := slices.IndexFunc(out, func(b jwalk.Entry) bool { return b.Key == e.Key })
+		if i < 0 {
+			out = append(out, jwalk.Entry{Key: e.Key, Value: jwalk.Array{}})
+			i = len(out) - 1
+		}
+		coll, ok := out[i].Value.(jwalk.Array)
+		if !ok {
+			return nil, fmt.Errorf("collection %q: seeded value is %T, not an array", e.Key, out[i].Value)
+		}
+		if coll, err = c.apply(slices.Clone(coll)); err != nil {
+			return nil, fmt.Errorf("collection %q: %w", e.Key, err)
+		}
+		out[i].Value = coll
+	}
+	return out, nil
+}
+
+func parseChanges(v any) (*collectionChanges, error) {
+	doc, ok := v.(jwalk.Document)
+	if !ok {
+		return nil, fmt.Errorf("expected object, got %T", v)
+	}
+	c := &collectionChanges{key: idKey}
+	for _, e := range doc {
+		if e.Key == "key" {
+			key, ok := e.Value.(string)
+			if !ok || key == "" {
+				return nil, errors.New(`"key" must be a non-empty string`)
+			}
+			c.key = key
+			continue
+		}
+		arr, ok := e.Value.(jwalk.Array)
+		if !ok {
+			return nil, fmt.Errorf("%s: expected array, got %T", e.Key, e.Value)
+		}
+		switch e.Key {
+		case "inserted":
+			c.inserted = arr
+		case "updated":
+			c.updated = arr
+		case "deleted":
+			c.deleted = arr
+		default:
+			return nil, fmt.Errorf("unknown field %q", e.Key)
+		}
+		for i, v := range arr {
+			if _, ok := v.(jwalk.Document); !ok {
+				return nil, fmt.Errorf("%s: index %d expects object, got %T", e.Key, i, v)
+			}
+		}
+	}
+	return c, nil
+}
+
+// apply patches and removes the documents of coll, then appends the inserted
+// documents.
+func (c *collectionChanges) apply(coll jwalk.Array) (jwalk.Array, error) {
+	for _, patch := range c.updated {
+		i, err := c.index(coll, patch)
+		if err != nil {
+			return nil, fmt.Errorf("updated: %w", err)
+		}
+		coll[i] = deepMerge(coll[i], patch)
+	}
+	for _, doc := range c.deleted {
+		i, err := c.index(coll, doc)
+		if err != nil {
+			return nil, fmt.Errorf("deleted: %w", err)
+		}
+		coll = slices.Delete(coll, i, i+1)
+	}
+	return append(coll, c.inserted...), nil
+}
+
+// index returns the position of the seeded document with the same key as doc.
+func (c *collectionChanges) index(coll jwalk.Array, doc any) (int, error) {
+	id, ok := documentKey(doc, c.key)
+	if !ok {
+		return 0, fmt.Errorf("document has no explicit %s", c.key)
+	}
+	i := slices.IndexFunc(coll, func(b any) bool {
+		bid, ok := documentKey(b, c.key)
+		return ok && reflect.DeepEqual(bid, id)
+	})
+	if i < 0 {
+		return 0, fmt.Errorf("no seeded document with %s %v", c.key, id)
+	}
+	return i, nil
+}
@@ -0,0 +1,78 @@
+package testine
+
+import (
+	"testing"
+
+	"github.com/calumari/jwalk"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSnapshot_AssertChanges(t *testing.T) {
+	seed := `{"users": [
+		{"_id": 1, "name": "Alice", "address": {"city": "Paris", "zip": "75001"}, "legacy": true},
+		{"_id": 2, "name": "Bob"}
+	]}`
+
+	t.Run("inserted, updated and deleted documents succeeds", func(t *testing.T) {
+		pt, driver := newMemoryT(t)
+		snap := pt.Seed(t, loadTemp(t, pt, seed))
+		driver.Mutate("users", func(docs jwalk.Array) jwalk.Array {
+			alice := docs[0].(jwalk.Document)
+			alice[2].Value = jwalk.Document{{Key: "city", Value: "Lyon"}, {Key: "zip", Value: "75001"}}
+			return jwalk.Array{alice[:3]}
+		})
+		driver.Insert("users", jwalk.Document{{Key: "_id", Value: 3.0}, {Key: "name", Value: "Carol"}})
+		driver.Insert("audit", jwalk.Document{{Key: "action", Value: "signup"}})
+
+		snap.AssertChanges(t, loadTemp(t, pt, `{
+			"users": {
+				"updated": [{"_id": 1, "address": {"city": "Lyon"}, "legacy": {"$absent": true}}],
+				"deleted": [{"_id": 2}],
+				"inserted": [{"_id": 3, "name": "Carol"}]
+			},
+			"audit": {"inserted": [{"action": "signup"}]}
+		}`))
+	})
+
+	t.Run("custom key succeeds", func(t *testing.T) {
+		pt, driver := newMemoryT(t)
+		snap := pt.Seed(t, jwalk.Document{{Key: "pets", Value: jwalk.Array{
+			jwalk.Document{{Key: "id", Value: "p1"}, {Key: "name", Value: "Luna"}},
+		}}})
+		driver.Mutate("pets", func(jwalk.Array) jwalk.Array { return jwalk.Array{} })
+
+		snap.AssertChanges(t, jwalk.Document{{Key: "pets", Value: jwalk.Document{
+			{Key: "key", Value: "id"},
+			{Key: "deleted", Value: jwalk.Array{jwalk.Document{{Key: "id", Value: "p1"}}}},
+		}}})
+	})
+
+	t.Run("unlisted change returns fatal", func(t *testing.T) {
+		pt, driver := newMemoryT(t)
+		snap := pt.Seed(t, loadTemp(t, pt, seed))
+		driver.Insert("users", jwalk.Document{{Key: "_id", Value: 3.0}, {Key: "name", Value: "Carol"}})
+		ft := &mockTestingT{}
+		snap.AssertChanges(ft, loadTemp(t, pt, `{"users": {"deleted": [{"_id": 2}]}}`))
+		assert.Contains(t, ft.fatal, "assert: ")
+	})
+
+	invalid := []struct {
+		name    string
+		changes string
+		want    string
+	}{
+		{"unknown document returns fatal", `{"users": {"updated": [{"_id": 9, "name": "x"}]}}`, `changes: collection "users": updated: no seeded document with _id 9`},
+		{"missing key returns fatal", `{"users": {"deleted": [{"name": "Bob"}]}}`, `changes: collection "users": deleted: document has no explicit _id`},
+		{"unknown field returns fatal", `{"users": {"replaced": []}}`, `changes: collection "users": unknown field "replaced"`},
+		{"non-object collection returns fatal", `{"users": []}`, `changes: collection "users": expected object, got jwalk.Array`},
+	}
+	for _, tt := range invalid {
+		t.Run(tt.name, func(t *testing.T) {
+			pt, _ := newMemoryT(t)
+			snap := pt.Seed(t, loadTemp(t, pt, seed))
+			ft := &mockTestingT{}
+			snap.AssertChanges(ft, loadTemp(t, pt, tt.changes))
+			assert.Equal(t, tt.want, ft.fatal)
+		})
+	}
+}
@@ -0,0 +1,38 @@
+package testine
+
+import (
+	"errors"
+
+	"github.com/calumari/poutine/database"
+)
+
+// Checkpoint saves the current state of the database and returns its
+// identifier for Restore, so a scenario can seed once and run several
+// branches from the same state. The driver must implement
+// database.Checkpointer.
+func (pt *T) Checkpoint(t TestingT) string {
+	t.Helper()
+	c, ok := pt.poutine.(database.Checkpointer)
+	if !ok {
+		t.Fatalf("checkpoint: %T: %v", pt.poutine, errors.ErrUnsupported)
+		return ""
+	}
+	id, err := c.Checkpoint(pt.context(t))
+	if err != nil {
+		t.Fatalf("checkpoint: %v", err)
+	}
+	return id
+}
+
+// Restore returns the database to the state saved by Checkpoint.
+func (pt *T) Restore(t TestingT, id string) {
+	t.Helper()
+	c, ok := pt.poutine.(database.Checkpointer)
+	if !ok {
+		t.Fatalf("restore checkpoint %s: %T: %v", id, pt.poutine, errors.ErrUnsupported)
+		return
+	}
+	if err := c.Restore(pt.context(t), id); err != nil {
+		t.Fatalf("restore checkpoint %s: %v", id, err)
+	}
+}
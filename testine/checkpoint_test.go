@@ -0,0 +1,47 @@
+package testine
+
+import (
+	"testing"
+
+	"github.com/calumari/jwalk"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/calumari/poutine"
+	"github.com/calumari/poutine/database"
+	"github.com/calumari/poutine/database/memory"
+)
+
+func TestT_Checkpoint(t *testing.T) {
+	seed := jwalk.Document{{Key: "pets", Value: jwalk.Array{jwalk.Document{{Key: "name", Value: "Luna"}}}}}
+
+	t.Run("restore runs branches from checkpoint succeeds", func(t *testing.T) {
+		pt, driver := newMemoryT(t)
+		pt.Seed(t, seed)
+		id := pt.Checkpoint(t)
+
+		driver.Insert("pets", jwalk.Document{{Key: "name", Value: "Milo"}})
+		pt.Restore(t, id)
+		pt.Assert(t, seed)
+
+		driver.Drop("pets")
+		pt.Restore(t, id)
+		pt.Assert(t, seed)
+	})
+
+	t.Run("unknown checkpoint returns fatal", func(t *testing.T) {
+		pt, _ := newMemoryT(t)
+		ft := &mockTestingT{}
+		pt.Restore(ft, "1")
+		assert.Equal(t, `restore checkpoint 1: unknown checkpoint "1"`, ft.fatal)
+	})
+
+	t.Run("unsupported driver returns fatal", func(t *testing.T) {
+		pt, err := New(poutine.New(struct{ database.Driver }{memory.NewDriver()}))
+		require.NoError(t, err)
+		ft := &mockTestingT{}
+		pt.Checkpoint(ft)
+		assert.Contains(t, ft.fatal, "checkpoint: ")
+		assert.Contains(t, ft.fatal, "unsupported operation")
+	})
+}
@@ -0,0 +1,255 @@
+package testine
+
+import (
+	"errors"
+	"fmt"
+	"path/filepath"
+	"reflect"
+	"slices"
+	"strings"
+
+	"github.com/calumari/jwalk"
+	"github.com/go-json-experiment/json/jsontext"
+)
+
+// idKey identifies the documents merged by MergeByID and, by default, the
+// documents overridden through $extends.
+const idKey = "_id"
+
+// extends is the decoded $extends entry of a fixture:
+//
+//	"$extends": "base.json"
+//	"$extends": {"file": "base.json", "key": "id"}
+type extends struct {
+	file string
+	key  string
+}
+
+// compose decodes file with decodeFile and resolves its top-level $include
+// and $extends entries. Included and extended paths are relative to file and
+// are decoded with decodeFile too; stack holds the files being composed and
+// guards against cycles.
+//
+// The result starts from the $extends base, merges the $include list on top
+// of it with the loader's merge strategy, like a directory, then applies the
+// file's own collections: with $extends their documents override base
+// documents with the same key field by field and are appended otherwise,
+// without it they replace whole collections.
+func (l *documentLoader) compose(file string, decodeFile func(string) (jwalk.Document, error), stack []string) (jwalk.Document, error) {
+	abs, err := filepath.Abs(file)
+	if err != nil {
+		return nil, err
+	}
+	if slices.Contains(stack, abs) {
+		return nil, fmt.Errorf("fixture cycle: %s", strings.Join(append(slices.Clone(stack), abs), " -> "))
+	}
+	stack = append(slices.Clip(stack), abs)
+
+	doc, err := decodeFile(file)
+	if err != nil {
+		return nil, err
+	}
+	var (
+		includes []string
+		base     *extends
+		own      = make(jwalk.Document, 0, len(doc))
+	)
+	for _, e := range doc {
+		switch e.Key {
+		case "$include":
+			if includes, err = includePaths(e.Value); err != nil {
+				return nil, fmt.Errorf("%s: $include: %w", file, err)
+			}
+		case "$extends":
+			if base, err = parseExtends(e.Value); err != nil {
+				return nil, fmt.Errorf("%s: $extends: %w", file, err)
+			}
+		default:
+			own = append(own, e)
+		}
+	}
+	if includes == nil && base == nil {
+		return doc, nil
+	}
+
+	load := func(path string) (jwalk.Document, error) {
+		if !filepath.IsAbs(path) {
+			path = filepath.Join(filepath.Dir(file), path)
+		}
+		return l.loadFiles(path, func(f string) (jwalk.Document, error) {
+			return l.compose(f, decodeFile, stack)
+		})
+	}
+	var baseDoc jwalk.Document
+	if base != nil {
+		if baseDoc, err = load(base.file); err != nil {
+			return nil, fmt.Errorf("%s: $extends %s: %w", file, base.file, err)
+		}
+	}
+	var included []sourcedDoc
+	for _, path := range includes {
+		d, err := load(path)
+		if err != nil {
+			return nil, fmt.Errorf("%s: $include %s: %w", file, path, err)
+		}
+		included = append(included, sourcedDoc{file: path, doc: d})
+	}
+	inc, err := l.merge.merge(included)
+	if err != nil {
+		return nil, fmt.Errorf("%s: $include: %w", file, err)
+	}
+	merged := mergeDocs(func(yield func(jwalk.Document)) {
+		yield(baseDoc)
+		yield(inc)
+	})
+	if base == nil {
+		return mergeDocs(func(yield func(jwalk.Document)) {
+			yield(merged)
+			yield(own)
+		}), nil
+	}
+	return extendDocs(merged, own, base.key), nil
+}
+
+func includePaths(v any) ([]string, error) {
+	switch val := v.(type) {
+	case string:
+		return []string{val}, nil
+	case jwalk.Array:
+		paths := make([]string, 0, len(val))
+		for _, p := range val {
+			s, ok := p.(string)
+			if !ok {
+				return nil, fmt.Errorf("expected path string, got %T", p)
+			}
+			paths = append(paths, s)
+		}
+		return paths, nil
+	default:
+		return nil, fmt.Errorf("expected path string or array, got %T", v)
+	}
+}
+
+func parseExtends(v any) (*extends, error) {
+	ext := &extends{key: idKey}
+	switch val := v.(type) {
+	case string:
+		ext.file = val
+	case jwalk.Document:
+		for _, e := range val {
+			s, ok := e.Value.(string)
+			if !ok {
+				return nil, fmt.Errorf("%s: expected string, got %T", e.Key, e.Value)
+			}
+			switch e.Key {
+			case "file":
+				ext.file = s
+			case "key":
+				ext.key = s
+			default:
+				return nil, fmt.Errorf("unknown field %q", e.Key)
+			}
+		}
+	default:
+		return nil, fmt.Errorf("expected path string or object, got %T", v)
+	}
+	if ext.file == "" || ext.key == "" {
+		return nil, errors.New(`"file" and "key" must not be empty`)
+	}
+	return ext, nil
+}
+
+// extendDocs applies the collections of own to base. Documents of a
+// collection present in both override the base document with the same key
+// and are appended otherwise; other values replace the base value.
+func extendDocs(base, own jwalk.Document, key string) jwalk.Document {
+	out := copyDoc(base)
+	for _, e := range own {
+		i := slices.IndexFunc(out, func(b jwalk.Entry) bool { return b.Key == e.Key })
+		if i < 0 {
+			out = append(out, e)
+			continue
+		}
+		baseColl, ok1 := out[i].Value.(jwalk.Array)
+		ownColl, ok2 := e.Value.(jwalk.Array)
+		if !ok1 || !ok2 {
+			out[i].Value = e.Value
+			continue
+		}
+		out[i].Value = extendCollection(baseColl, ownColl, key)
+	}
+	return out
+}
+
+func extendCollection(base, own jwalk.Array, key string) jwalk.Array {
+	out := slices.Clone(base)
+	for _, v := range own {
+		id, ok := documentKey(v, key)
+		if !ok {
+			out = append(out, v)
+			continue
+		}
+		i := slices.IndexFunc(out, func(b any) bool {
+			bid, ok := documentKey(b, key)
+			return ok && reflect.DeepEqual(bid, id)
+		})
+		if i < 0 {
+			out = append(out, v)
+			continue
+		}
+		out[i] = overrideFields(out[i].(jwalk.Document), v.(jwalk.Document))
+	}
+	return out
+}
+
+// documentKey returns the key field of a document. Wildcards such as
+// {"$oid": true} never identify a document.
+func documentKey(v any, key string) (any, bool) {
+	doc, ok := v.(jwalk.Document)
+	if !ok {
+		return nil, false
+	}
+	i := slices.IndexFunc(doc, func(e jwalk.Entry) bool { return e.Key == key })
+	if i < 0 {
+		return nil, false
+	}
+	id := doc[i].Value
+	if p, ok := id.(interface {
+		IsExplicit() bool
+		UnwrapValue() any
+	}); ok {
+		if !p.IsExplicit() {
+			return nil, false
+		}
+		id = p.UnwrapValue()
+	}
+	return id, true
+}
+
+// overrideFields returns base with the fields of override replacing or
+// appended to its own.
+func overrideFields(base, override jwalk.Document) jwalk.Document {
+	out := copyDoc(base)
+	for _, e := range override {
+		if i := slices.IndexFunc(out, func(b jwalk.Entry) bool { return b.Key == e.Key }); i >= 0 {
+			out[i].Value = e.Value
+			continue
+		}
+		out = append(out, e)
+	}
+	return out
+}
+
+// isComposed reports whether the fixture at file has top-level $include or
+// $extends entries. Composed fixtures are not rewritten in update mode.
+func isComposed(file string) bool {
+	data, err := readFixture(file)
+	if err != nil {
+		return false
+	}
+	raw, err := parseRaw(jsontext.Value(data))
+	if err != nil {
+		return false
+	}
+	return raw.member("$include") != nil || raw.member("$extends") != nil
+}
@@ -0,0 +1,200 @@
+package testine
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/calumari/jwalk"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/calumari/poutine/database/memory"
+	"github.com/calumari/poutine/exp"
+)
+
+func newComposeLoader(t *testing.T) *documentLoader {
+	t.Helper()
+	reg, err := jwalk.NewRegistry(jwalk.WithDirective(idDirective))
+	require.NoError(t, err)
+	return newDocumentLoader(reg, false, MergeReplace)
+}
+
+func idDoc(id any, fields ...jwalk.Entry) jwalk.Document {
+	return append(jwalk.Document{{Key: "id", Value: id}}, fields...)
+}
+
+func Test_documentLoader_compose(t *testing.T) {
+	t.Run("include merges collections succeeds", func(t *testing.T) {
+		dir := writeDirFiles(t, map[string]string{
+			"tenant.json": `{"tenants": [{"name": "acme"}], "users": [{"name": "base"}]}`,
+			"test.json":   `{"$include": "tenant.json", "users": [{"name": "alice"}]}`,
+		})
+		got, err := newComposeLoader(t).load(filepath.Join(dir, "test.json"))
+		require.NoError(t, err)
+		assert.Equal(t, jwalk.Document{
+			{Key: "tenants", Value: jwalk.Array{jwalk.Document{{Key: "name", Value: "acme"}}}},
+			{Key: "users", Value: jwalk.Array{jwalk.Document{{Key: "name", Value: "alice"}}}},
+		}, got)
+	})
+
+	t.Run("include list, nested include and other formats succeed", func(t *testing.T) {
+		dir := writeDirFiles(t, map[string]string{
+			"a.yaml":    "a: 1\n",
+			"b.json":    `{"$include": "a.yaml", "b": 2}`,
+			"test.json": `{"$include": ["b.json", "c.jsonc"], "d": 4}`,
+			"c.jsonc": `{"c": 3, // comment
+			}`,
+		})
+		got, err := newComposeLoader(t).load(filepath.Join(dir, "test.json"))
+		require.NoError(t, err)
+		assert.Equal(t, jwalk.Document{{Key: "a", Value: 1.0}, {Key: "b", Value: 2.0}, {Key: "c", Value: 3.0}, {Key: "d", Value: 4.0}}, got)
+	})
+
+	t.Run("include of directory resolves relative to file succeeds", func(t *testing.T) {
+		dir := writeDirFiles(t, map[string]string{
+			"test.json": `{"$include": "shared"}`,
+		})
+		require.NoError(t, os.Mkdir(filepath.Join(dir, "shared"), 0o755))
+		require.NoError(t, os.WriteFile(filepath.Join(dir, "shared", "a.json"), []byte(`{"a": 1}`), 0o644))
+		got, err := newComposeLoader(t).load(filepath.Join(dir, "test.json"))
+		require.NoError(t, err)
+		assert.Equal(t, jwalk.Document{{Key: "a", Value: 1.0}}, got)
+	})
+
+	t.Run("extends overrides and appends documents by key succeeds", func(t *testing.T) {
+		dir := writeDirFiles(t, map[string]string{
+			"base.json": `{
+				"tenants": [{"name": "acme"}],
+				"users": [
+					{"id": {"$id": "u1"}, "name": "alice", "role": "admin"},
+					{"id": {"$id": "u2"}, "name": "bob", "role": "user"},
+					{"id": {"$id": true}, "name": "generated"}
+				]
+			}`,
+			"test.json": `{
+				"$extends": {"file": "base.json", "key": "id"},
+				"users": [
+					{"id": {"$id": "u2"}, "role": "admin"},
+					{"id": {"$id": "u3"}, "name": "carol"},
+					{"id": {"$id": true}, "name": "generated"}
+				],
+				"orders": []
+			}`,
+		})
+		got, err := newComposeLoader(t).load(filepath.Join(dir, "test.json"))
+		require.NoError(t, err)
+		assert.Equal(t, jwalk.Document{
+			{Key: "tenants", Value: jwalk.Array{jwalk.Document{{Key: "name", Value: "acme"}}}},
+			{Key: "users", Value: jwalk.Array{
+				idDoc(exp.Value("u1"), jwalk.Entry{Key: "name", Value: "alice"}, jwalk.Entry{Key: "role", Value: "admin"}),
+				idDoc(exp.Value("u2"), jwalk.Entry{Key: "name", Value: "bob"}, jwalk.Entry{Key: "role", Value: "admin"}),
+				idDoc(exp.Any("generated"), jwalk.Entry{Key: "name", Value: "generated"}),
+				idDoc(exp.Value("u3"), jwalk.Entry{Key: "name", Value: "carol"}),
+				idDoc(exp.Any("generated"), jwalk.Entry{Key: "name", Value: "generated"}),
+			}},
+			{Key: "orders", Value: jwalk.Array{}},
+		}, got)
+	})
+
+	t.Run("extends uses _id by default succeeds", func(t *testing.T) {
+		dir := writeDirFiles(t, map[string]string{
+			"base.json": `{"users": [{"_id": 1, "name": "alice"}]}`,
+			"test.json": `{"$extends": "base.json", "users": [{"_id": 1, "name": "alicia"}]}`,
+		})
+		got, err := newComposeLoader(t).load(filepath.Join(dir, "test.json"))
+		require.NoError(t, err)
+		assert.Equal(t, jwalk.Document{{Key: "users", Value: jwalk.Array{
+			jwalk.Document{{Key: "_id", Value: 1.0}, {Key: "name", Value: "alicia"}},
+		}}}, got)
+	})
+
+	t.Run("cached load does not change base succeeds", func(t *testing.T) {
+		dir := writeDirFiles(t, map[string]string{
+			"base.json": `{"users": [{"_id": 1, "name": "alice"}]}`,
+			"test.json": `{"$extends": "base.json", "users": [{"_id": 1, "name": "alicia"}]}`,
+		})
+		l := newDocumentLoader(&jwalk.Registry{}, true, MergeReplace)
+		_, err := l.load(filepath.Join(dir, "test.json"))
+		require.NoError(t, err)
+		got, err := l.load(filepath.Join(dir, "base.json"))
+		require.NoError(t, err)
+		assert.Equal(t, jwalk.Document{{Key: "users", Value: jwalk.Array{
+			jwalk.Document{{Key: "_id", Value: 1.0}, {Key: "name", Value: "alice"}},
+		}}}, got)
+	})
+
+	t.Run("template expands included files succeeds", func(t *testing.T) {
+		dir := writeDirFiles(t, map[string]string{
+			"base.json": `{"users": [{"name": "${name}"}]}`,
+			"test.json": `{"$include": "base.json"}`,
+		})
+		got, err := newComposeLoader(t).loadTemplate(filepath.Join(dir, "test.json"), newTemplate(map[string]any{"name": "alice"}, nil))
+		require.NoError(t, err)
+		assert.Equal(t, jwalk.Document{{Key: "users", Value: jwalk.Array{
+			jwalk.Document{{Key: "name", Value: "alice"}},
+		}}}, got)
+	})
+
+	invalid := []struct {
+		name  string
+		files map[string]string
+	}{
+		{"include cycle returns error", map[string]string{
+			"test.json": `{"$include": "a.json"}`,
+			"a.json":    `{"$include": "test.json"}`,
+		}},
+		{"self extends returns error", map[string]string{
+			"test.json": `{"$extends": "test.json"}`,
+		}},
+		{"missing include returns error", map[string]string{
+			"test.json": `{"$include": "missing.json"}`,
+		}},
+		{"invalid include returns error", map[string]string{
+			"test.json": `{"$include": 1}`,
+		}},
+		{"unknown extends field returns error", map[string]string{
+			"test.json": `{"$extends": {"file": "a.json", "by": "id"}}`,
+		}},
+		{"empty extends key returns error", map[string]string{
+			"test.json": `{"$extends": {"file": "a.json", "key": ""}}`,
+		}},
+	}
+	for _, tt := range invalid {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := writeDirFiles(t, tt.files)
+			_, err := newComposeLoader(t).load(filepath.Join(dir, "test.json"))
+			assert.Error(t, err)
+		})
+	}
+}
+
+func TestT_LoadJSON_composedUpdate(t *testing.T) {
+	t.Run("update mode does not rewrite composed fixture succeeds", func(t *testing.T) {
+		dir := writeDirFiles(t, map[string]string{
+			"base.json": `{"pets": [{"name": "Luna"}]}`,
+			"test.json": `{"$include": "base.json"}`,
+		})
+		pt := newGoldenT(t, memory.NewDriver(), WithUpdate())
+		doc := pt.LoadJSON(t, filepath.Join(dir, "test.json"))
+		_, ok := pt.source(doc)
+		assert.False(t, ok)
+	})
+
+	t.Run("update mode with cache reloads files including rewritten fixture succeeds", func(t *testing.T) {
+		dir := writeDirFiles(t, map[string]string{
+			"base.json": `{"pets": [{"name": "Luna"}]}`,
+			"test.json": `{"$include": "base.json"}`,
+		})
+		driver := memory.NewDriver()
+		pt := newGoldenT(t, driver, WithUpdate(), WithDocumentCache())
+		pt.LoadJSON(t, filepath.Join(dir, "test.json"))
+		driver.Insert("pets", jwalk.Document{{Key: "name", Value: "Max"}})
+
+		pt.Assert(t, pt.LoadJSON(t, filepath.Join(dir, "base.json")))
+		doc := pt.LoadJSON(t, filepath.Join(dir, "test.json"))
+		assert.Equal(t, jwalk.Document{{Key: "pets", Value: jwalk.Array{
+			jwalk.Document{{Key: "name", Value: "Max"}},
+		}}}, doc)
+	})
+}
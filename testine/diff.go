@@ -0,0 +1,215 @@
+package testine
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/calumari/jwalk"
+
+	"github.com/calumari/poutine/exp"
+)
+
+const (
+	colorRed   = "\x1b[31m"
+	colorGreen = "\x1b[32m"
+	colorCyan  = "\x1b[36m"
+	colorReset = "\x1b[0m"
+)
+
+type wildcard interface {
+	IsWildcard() bool
+}
+
+// diffLine is a single rendered line of a hunk. op is ' ' for context, '-'
+// for expected-only and '+' for actual-only lines.
+type diffLine struct {
+	op   byte
+	text string
+}
+
+// differ renders a path-annotated unified diff of an expected and actual
+// snapshot. Each collection element forms a hunk; only hunks containing a
+// mismatch are printed. Leaf equality is decided by the Tester so rules and
+// patterns behave exactly as they do during the assertion.
+type differ struct {
+	tester Tester
+	color  bool
+}
+
+func (d *differ) render(expected, actual jwalk.Document) string {
+	var sb strings.Builder
+	d.header(&sb, "--- expected", colorRed)
+	d.header(&sb, "+++ actual", colorGreen)
+	changed := false
+	for _, e := range expected {
+		av, ok := lookup(actual, e.Key)
+		if !ok {
+			if exp.IsAbsent(e.Value) {
+				continue
+			}
+			changed = true
+			d.hunk(&sb, e.Key, []diffLine{{'-', e.Key + ": " + formatValue(e.Value)}})
+			continue
+		}
+		expArr, expOK := e.Value.(jwalk.Array)
+		actArr, actOK := av.(jwalk.Array)
+		if !expOK || !actOK {
+			var lines []diffLine
+			if d.walk(&lines, e.Key, e.Value, av) {
+				changed = true
+				d.hunk(&sb, e.Key, lines)
+			}
+			continue
+		}
+		for i := range max(len(expArr), len(actArr)) {
+			path := e.Key + "[" + strconv.Itoa(i) + "]"
+			var lines []diffLine
+			switch {
+			case i >= len(actArr):
+				lines = append(lines, diffLine{'-', path + ": " + formatValue(expArr[i])})
+			case i >= len(expArr):
+				lines = append(lines, diffLine{'+', path + ": " + formatValue(actArr[i])})
+			default:
+				if !d.walk(&lines, path, expArr[i], actArr[i]) {
+					continue
+				}
+			}
+			changed = true
+			d.hunk(&sb, path, lines)
+		}
+	}
+	if !changed {
+		return ""
+	}
+	return sb.String()
+}
+
+// walk appends the lines for path and reports whether any of them differ.
+func (d *differ) walk(lines *[]diffLine, path string, expected, actual any) bool {
+	switch want := expected.(type) {
+	case jwalk.Document:
+		act, ok := actual.(jwalk.Document)
+		if !ok {
+			break
+		}
+		changed := false
+		for _, e := range want {
+			p := path + "." + e.Key
+			av, ok := lookup(act, e.Key)
+			if !ok {
+				if exp.IsAbsent(e.Value) {
+					*lines = append(*lines, diffLine{' ', p + ": (absent)"})
+					continue
+				}
+				*lines = append(*lines, diffLine{'-', p + ": " + formatValue(e.Value)})
+				changed = true
+				continue
+			}
+			if d.walk(lines, p, e.Value, av) {
+				changed = true
+			}
+		}
+		return changed
+	case jwalk.Array:
+		act, ok := actual.(jwalk.Array)
+		if !ok {
+			break
+		}
+		changed := false
+		for i := range max(len(want), len(act)) {
+			p := path + "[" + strconv.Itoa(i) + "]"
+			switch {
+			case i >= len(act):
+				*lines = append(*lines, diffLine{'-', p + ": " + formatValue(want[i])})
+				changed = true
+			case i >= len(want):
+				*lines = append(*lines, diffLine{'+', p + ": " + formatValue(act[i])})
+				changed = true
+			default:
+				if d.walk(lines, p, want[i], act[i]) {
+					changed = true
+				}
+			}
+		}
+		return changed
+	}
+	if err := d.tester.Test(expected, actual); err != nil {
+		*lines = append(*lines,
+			diffLine{'-', path + ": " + formatValue(expected)},
+			diffLine{'+', path + ": " + formatValue(actual)},
+		)
+		return true
+	}
+	text := path + ": " + formatValue(actual)
+	if w, ok := expected.(wildcard); ok && w.IsWildcard() {
+		text += " (matched wildcard)"
+	}
+	*lines = append(*lines, diffLine{' ', text})
+	return false
+}
+
+func (d *differ) header(sb *strings.Builder, text, color string) {
+	d.write(sb, text, color)
+}
+
+func (d *differ) hunk(sb *strings.Builder, path string, lines []diffLine) {
+	d.write(sb, "@@ "+path+" @@", colorCyan)
+	for _, l := range lines {
+		text := string(l.op) + " " + l.text
+		switch l.op {
+		case '-':
+			d.write(sb, text, colorRed)
+		case '+':
+			d.write(sb, text, colorGreen)
+		default:
+			d.write(sb, text, "")
+		}
+	}
+}
+
+func (d *differ) write(sb *strings.Builder, text, color string) {
+	if d.color && color != "" {
+		sb.WriteString(color)
+		sb.WriteString(text)
+		sb.WriteString(colorReset)
+	} else {
+		sb.WriteString(text)
+	}
+	sb.WriteByte('\n')
+}
+
+func lookup(doc jwalk.Document, key string) (any, bool) {
+	for _, e := range doc {
+		if e.Key == key {
+			return e.Value, true
+		}
+	}
+	return nil, false
+}
+
+// formatValue renders a value on a single line using JSON-like notation.
+func formatValue(v any) string {
+	switch val := v.(type) {
+	case nil:
+		return "null"
+	case string:
+		return strconv.Quote(val)
+	case jwalk.Document:
+		parts := make([]string, 0, len(val))
+		for _, e := range val {
+			parts = append(parts, strconv.Quote(e.Key)+": "+formatValue(e.Value))
+		}
+		return "{" + strings.Join(parts, ", ") + "}"
+	case jwalk.Array:
+		parts := make([]string, 0, len(val))
+		for _, e := range val {
+			parts = append(parts, formatValue(e))
+		}
+		return "[" + strings.Join(parts, ", ") + "]"
+	case fmt.Stringer:
+		return val.String()
+	default:
+		return fmt.Sprintf("%v", v)
+	}
+}
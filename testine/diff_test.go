@@ -0,0 +1,96 @@
+package testine
+
+import (
+	"testing"
+
+	"github.com/calumari/jwalk"
+	"github.com/calumari/testequals"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/calumari/poutine/exp"
+)
+
+func Test_differ_render(t *testing.T) {
+	d := &differ{tester: testequals.New()}
+
+	t.Run("equal documents returns empty diff", func(t *testing.T) {
+		doc := docKV("pets", jwalk.Array{docKV("name", "Luna")})
+		assert.Empty(t, d.render(doc, doc))
+	})
+
+	t.Run("field mismatch renders hunk with context", func(t *testing.T) {
+		expected := docKV("pets", jwalk.Array{
+			jwalk.Document{{Key: "name", Value: "Luna"}, {Key: "age", Value: 3}},
+			jwalk.Document{{Key: "name", Value: "Max"}, {Key: "age", Value: 5}},
+		})
+		actual := docKV("pets", jwalk.Array{
+			jwalk.Document{{Key: "name", Value: "Luna"}, {Key: "age", Value: 3}},
+			jwalk.Document{{Key: "name", Value: "Bella"}, {Key: "age", Value: 5}},
+		})
+		want := "--- expected\n" +
+			"+++ actual\n" +
+			"@@ pets[1] @@\n" +
+			"- pets[1].name: \"Max\"\n" +
+			"+ pets[1].name: \"Bella\"\n" +
+			"  pets[1].age: 5\n"
+		assert.Equal(t, want, d.render(expected, actual))
+	})
+
+	t.Run("wildcard pattern renders as matched", func(t *testing.T) {
+		expected := docKV("pets", jwalk.Array{
+			jwalk.Document{{Key: "id", Value: exp.Any("generated")}, {Key: "name", Value: "Max"}},
+		})
+		actual := docKV("pets", jwalk.Array{
+			jwalk.Document{{Key: "id", Value: "abc"}, {Key: "name", Value: "Bella"}},
+		})
+		want := "--- expected\n" +
+			"+++ actual\n" +
+			"@@ pets[0] @@\n" +
+			"  pets[0].id: \"abc\" (matched wildcard)\n" +
+			"- pets[0].name: \"Max\"\n" +
+			"+ pets[0].name: \"Bella\"\n"
+		assert.Equal(t, want, d.render(expected, actual))
+	})
+
+	t.Run("length mismatch renders missing and extra elements", func(t *testing.T) {
+		expected := jwalk.Document{
+			{Key: "owners", Value: jwalk.Array{docKV("name", "Ann")}},
+			{Key: "pets", Value: jwalk.Array{docKV("name", "Luna"), docKV("name", "Max")}},
+		}
+		actual := jwalk.Document{
+			{Key: "owners", Value: jwalk.Array{docKV("name", "Ann"), docKV("name", "Bob")}},
+			{Key: "pets", Value: jwalk.Array{docKV("name", "Luna")}},
+		}
+		want := "--- expected\n" +
+			"+++ actual\n" +
+			"@@ owners[1] @@\n" +
+			"+ owners[1]: {\"name\": \"Bob\"}\n" +
+			"@@ pets[1] @@\n" +
+			"- pets[1]: {\"name\": \"Max\"}\n"
+		assert.Equal(t, want, d.render(expected, actual))
+	})
+
+	t.Run("missing collection and field render as removed", func(t *testing.T) {
+		expected := jwalk.Document{
+			{Key: "owners", Value: jwalk.Array{}},
+			{Key: "pets", Value: jwalk.Array{jwalk.Document{{Key: "name", Value: "Luna"}, {Key: "tags", Value: jwalk.Array{"cat"}}}}},
+		}
+		actual := docKV("pets", jwalk.Array{docKV("name", "Luna")})
+		want := "--- expected\n" +
+			"+++ actual\n" +
+			"@@ owners @@\n" +
+			"- owners: []\n" +
+			"@@ pets[0] @@\n" +
+			"  pets[0].name: \"Luna\"\n" +
+			"- pets[0].tags: [\"cat\"]\n"
+		assert.Equal(t, want, d.render(expected, actual))
+	})
+
+	t.Run("color enabled wraps changed lines", func(t *testing.T) {
+		cd := &differ{tester: testequals.New(), color: true}
+		got := cd.render(docKV("pets", jwalk.Array{docKV("name", "Max")}), docKV("pets", jwalk.Array{docKV("name", "Bella")}))
+		assert.Contains(t, got, colorCyan+"@@ pets[0] @@"+colorReset)
+		assert.Contains(t, got, colorRed+"- pets[0].name: \"Max\""+colorReset)
+		assert.Contains(t, got, colorGreen+"+ pets[0].name: \"Bella\""+colorReset)
+	})
+}
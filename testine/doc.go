@@ -0,0 +1,51 @@
+// Package testine provides test helpers that seed a database from fixture
+// files, snapshot it and assert its state against expected fixtures.
+//
+// # Fixture Files
+//
+// LoadJSON reads a file, a directory or a glob. Fixtures may be written in
+// JSON, JSONC (.jsonc), JSON5 (.json5) or YAML (.yaml, .yml); every format is
+// converted to JSON first, so directives such as {"$oid": true} behave the
+// same in each. Files loaded together are merged in lexical order according
+// to WithMergeStrategy.
+//
+// A top-level "$include" merges other fixtures into the file, and
+// "$extends" starts from a base fixture and overrides its documents by key:
+//
+//	{
+//	  "$extends": {"file": "baseline.json", "key": "_id"},
+//	  "$include": ["plans.yaml"],
+//	  "users": [{"_id": 1, "role": "admin"}]
+//	}
+//
+// LoadJSONWith expands the fixture as a template first: "${expr}" is replaced
+// by the value of a variable or arithmetic expression, and an array element
+// {"$repeat": n, "each": value} by n copies of value, with the index as i.
+//
+// # Directives
+//
+// Besides the directives of the driver, fixtures may use:
+//
+//   - {"$fake": "email"}, {"$fake": "int", "min": 1, "max": 9}: reproducible
+//     fake data; the seed is reported when a test fails and set with
+//     WithFakeSeed or -poutine.seed
+//   - {"$regex": ...}, {"$gt": ...}, {"$in": [...]}, {"$len": ...},
+//     {"$contains": ...}, {"$type": ...}, {"$not": ...}, {"$anyOf": [...]}:
+//     the matchers of package exp, for expected fixtures
+//   - {"$absent": true} and {"$null": true}: a field that must be missing, or
+//     present and null
+//
+// # References
+//
+// Directives that support them accept "@name" in place of a value, such as
+// {"$oid": "@alice"}. Seed generates the value of a name once and reuses it;
+// Assert requires every occurrence to match the same value. "?name" captures
+// the matched value on every successful Assert, which returns all bindings.
+// Each test has its own names.
+//
+// # Updating Fixtures
+//
+// With -poutine.update or WithUpdate, a failing Assert against a fixture
+// loaded from a single JSON file rewrites the file with the actual snapshot,
+// keeping the directives that still match.
+package testine
@@ -0,0 +1,68 @@
+package testine
+
+import (
+	"bytes"
+	"os"
+
+	"github.com/calumari/jwalk"
+	"github.com/go-json-experiment/json"
+	"github.com/go-json-experiment/json/jsontext"
+)
+
+// marshalJSON encodes doc as indented fixture JSON.
+func marshalJSON(doc jwalk.Document, marshalers *json.Marshalers) ([]byte, error) {
+	var buf bytes.Buffer
+	enc := newFixtureEncoder(&buf)
+	if err := encodeValue(enc, doc, marshalers); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+func newFixtureEncoder(buf *bytes.Buffer) *jsontext.Encoder {
+	return jsontext.NewEncoder(buf, jsontext.Multiline(true), jsontext.WithIndent("  "))
+}
+
+// encodeValue writes a snapshot value as JSON, encoding documents as objects
+// in key order and driver values with the driver's marshalers.
+func encodeValue(enc *jsontext.Encoder, v any, marshalers *json.Marshalers) error {
+	switch val := v.(type) {
+	case jwalk.Document:
+		if err := enc.WriteToken(jsontext.BeginObject); err != nil {
+			return err
+		}
+		for _, e := range val {
+			if err := enc.WriteToken(jsontext.String(e.Key)); err != nil {
+				return err
+			}
+			if err := encodeValue(enc, e.Value, marshalers); err != nil {
+				return err
+			}
+		}
+		return enc.WriteToken(jsontext.EndObject)
+	case jwalk.Array:
+		if err := enc.WriteToken(jsontext.BeginArray); err != nil {
+			return err
+		}
+		for _, e := range val {
+			if err := encodeValue(enc, e, marshalers); err != nil {
+				return err
+			}
+		}
+		return enc.WriteToken(jsontext.EndArray)
+	default:
+		if marshalers == nil {
+			return json.MarshalEncode(enc, v)
+		}
+		return json.MarshalEncode(enc, v, json.WithMarshalers(marshalers))
+	}
+}
+
+// writeJSON writes doc as fixture JSON to path.
+func writeJSON(path string, doc jwalk.Document, marshalers *json.Marshalers) error {
+	data, err := marshalJSON(doc, marshalers)
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, data, 0o644)
+}
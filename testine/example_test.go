@@ -0,0 +1,114 @@
+package testine_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/calumari/poutine"
+	"github.com/calumari/poutine/database"
+	"github.com/calumari/poutine/database/memory"
+	"github.com/calumari/poutine/testine"
+)
+
+// The examples use the in-memory driver; any poutine driver works the same.
+var t *testing.T
+
+func ExampleNew() {
+	ti, err := testine.New(poutine.New(memory.NewDriver()))
+	if err != nil {
+		t.Fatalf("create test helper: %v", err)
+	}
+	ti.Cleanup(t) // tear the database down after the test
+
+	snap := ti.Seed(t, ti.LoadJSON(t, "testdata/seed.json"))
+	// ... run code that modifies the database ...
+	snap.Assert(t) // assert no unintended mutations
+	// or assert against a specific expected state
+	ti.Assert(t, ti.LoadJSON(t, "testdata/after.json"))
+}
+
+func ExampleNewPool() {
+	pool := testine.NewPool(database.FactoryFunc[*memory.Driver](func(context.Context, string) (*memory.Driver, error) {
+		return memory.NewDriver(), nil
+	}))
+
+	// each test, parallel or not, gets its own database
+	t.Parallel()
+	ti := pool.For(t)
+	ti.Seed(t, ti.LoadJSON(t, "testdata/seed.json"))
+	_ = pool.Driver(t) // the driver to build the code under test with
+}
+
+func ExampleT_Begin() {
+	ti, _ := testine.New(poutine.New(memory.NewDriver()))
+
+	ctx := ti.Begin(t) // instead of ti.Cleanup(t)
+	ti.Seed(t, ti.LoadJSON(t, "testdata/seed.json"))
+	createPet(ctx) // the code under test must use ctx
+	ti.Assert(t, ti.LoadJSON(t, "testdata/after.json"))
+}
+
+func ExampleT_Checkpoint() {
+	ti, _ := testine.New(poutine.New(memory.NewDriver()))
+	ti.Seed(t, ti.LoadJSON(t, "testdata/seed.json"))
+	cp := ti.Checkpoint(t)
+
+	t.Run("cancel order", func(t *testing.T) {
+		ti.Restore(t, cp)
+		// ...
+	})
+	t.Run("ship order", func(t *testing.T) {
+		ti.Restore(t, cp)
+		// ...
+	})
+}
+
+func ExampleT_Assert_capture() {
+	ti, _ := testine.New(poutine.New(memory.NewDriver()))
+
+	// order_created.json: {"orders": [{"_id": {"$oid": "?orderId"}}]}
+	ids := ti.Assert(t, ti.LoadJSON(t, "testdata/order_created.json"))
+	shipOrder(ids["orderId"])
+
+	// order_shipped.json refers to the captured value with "@orderId"
+	ti.Assert(t, ti.LoadJSON(t, "testdata/order_shipped.json"))
+}
+
+func ExampleT_LoadJSONWith() {
+	ti, _ := testine.New(poutine.New(memory.NewDriver()))
+
+	// orders.json: {"orders": [{"$repeat": "${count}", "each": {"customer": "${customer}", "number": "${i + 1}"}}]}
+	ti.Seed(t, ti.LoadJSONWith(t, "testdata/orders.json", map[string]any{
+		"count":    100,
+		"customer": "alice",
+	}))
+}
+
+func ExampleSnapshot_AssertChanges() {
+	ti, _ := testine.New(poutine.New(memory.NewDriver()))
+	snap := ti.Seed(t, ti.LoadJSON(t, "testdata/seed.json"))
+	// ... run code that modifies the database ...
+
+	// changes.json lists documents by collection:
+	// {"users": {"inserted": [...], "updated": [{"_id": 1, "name": "Bob"}], "deleted": [{"_id": 2}]}}
+	snap.AssertChanges(t, ti.LoadJSON(t, "testdata/changes.json"))
+}
+
+func ExampleWithUnordered() {
+	ti, _ := testine.New(poutine.New(memory.NewDriver()),
+		testine.WithUnordered("events"),   // compare "events" as a multiset
+		testine.WithSortBy("_id", "users"), // sort "users" by _id on both sides
+	)
+	ti.Assert(t, ti.LoadJSON(t, "testdata/after.json"))
+}
+
+func ExampleWithMergeStrategy() {
+	ti, _ := testine.New(poutine.New(memory.NewDriver()), testine.WithMergeStrategy(testine.MergeByID))
+
+	// documents of testdata/seed/*.json with the same _id are deep-merged
+	ti.Seed(t, ti.LoadJSON(t, "testdata/seed"))
+}
+
+func createPet(context.Context) {}
+
+func shipOrder(any) {}
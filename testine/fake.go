@@ -0,0 +1,222 @@
+package testine
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"math"
+	"math/rand/v2"
+	"runtime"
+	"strings"
+	"sync"
+	"weak"
+
+	"github.com/calumari/jwalk"
+	"github.com/go-json-experiment/json"
+	"github.com/go-json-experiment/json/jsontext"
+
+	"github.com/calumari/poutine/exp"
+)
+
+var seedFlag = flag.Uint64("poutine.seed", 0, "seed for $fake fixture data; 0 picks a random seed that is reported on failure")
+
+// WithFakeSeed seeds the generator behind the $fake directive, reproducing
+// the data of an earlier run. Without it the -poutine.seed test flag is used,
+// or a random seed that is reported when the test fails.
+func WithFakeSeed(seed uint64) Option {
+	return func(o *Options) { o.fakeSeed = seed }
+}
+
+var (
+	fakeFirstNames = []string{"Alice", "Bob", "Carol", "Dave", "Erin", "Frank", "Grace", "Heidi", "Ivan", "Judy", "Mallory", "Niaj", "Olivia", "Peggy", "Rupert", "Sybil", "Trent", "Victor", "Walter", "Yara"}
+	fakeLastNames  = []string{"Anderson", "Brown", "Clark", "Davis", "Evans", "Garcia", "Harris", "Jackson", "Johnson", "Lee", "Martin", "Miller", "Moore", "Nguyen", "Robinson", "Smith", "Taylor", "Thomas", "Walker", "Wilson"}
+	fakeWords      = []string{"alpha", "amber", "basil", "cedar", "delta", "ember", "fable", "garnet", "harbor", "indigo", "jasper", "kettle", "lumen", "maple", "nectar", "orbit", "pepper", "quartz", "river", "saffron", "timber", "umber", "velvet", "willow", "zephyr"}
+)
+
+// maxFakeInt bounds $fake int values to the integers float64 represents
+// exactly.
+const maxFakeInt = 1 << 53
+
+// faker generates reproducible fixture data from a seed. It is shared by
+// every document a T loads, so the values depend on the seed and on the
+// order in which fixtures are loaded.
+type faker struct {
+	seed uint64
+
+	mu   sync.Mutex
+	rand *rand.Rand
+	seq  int
+	used bool
+}
+
+func newFaker(seed uint64) *faker {
+	if seed == 0 {
+		seed = rand.Uint64() | 1 // never 0, which means "unset"
+	}
+	return &faker{seed: seed, rand: rand.New(rand.NewPCG(seed, seed))}
+}
+
+// fakers holds the faker behind the $fake directive of each registry New
+// has filled. Keys are weak so that registries can still be collected.
+var (
+	fakersMu sync.Mutex
+	fakers   = map[weak.Pointer[jwalk.Registry]]*faker{}
+)
+
+// registerFaker adds $fake to reg, seeded with seed, and returns its faker.
+// A registry that already has one keeps it, as its directive draws from it;
+// seed must then be 0 or the seed it was created with.
+func registerFaker(reg *jwalk.Registry, seed uint64) (*faker, error) {
+	fakersMu.Lock()
+	defer fakersMu.Unlock()
+	key := weak.Make(reg)
+	if f, ok := fakers[key]; ok {
+		if seed != 0 && seed != f.seed {
+			return nil, fmt.Errorf("$fake seed %d: registry already draws from seed %d", seed, f.seed)
+		}
+		return f, nil
+	}
+	f := newFaker(seed)
+	if err := reg.Register(f.directive()); err != nil {
+		return nil, err
+	}
+	fakers[key] = f
+	runtime.AddCleanup(reg, func(key weak.Pointer[jwalk.Registry]) {
+		fakersMu.Lock()
+		delete(fakers, key)
+		fakersMu.Unlock()
+	}, key)
+	return f, nil
+}
+
+// note describes how to reproduce the generated data, or returns "" when no
+// $fake directive was decoded.
+func (f *faker) note() string {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if !f.used {
+		return ""
+	}
+	return fmt.Sprintf("\nfake data seed: %d (reproduce with -poutine.seed=%d)", f.seed, f.seed)
+}
+
+// fakeRange holds the bounds of numeric kinds.
+type fakeRange struct {
+	min, max *float64
+}
+
+// directive returns the $fake directive drawing from f:
+//
+//	{"$fake": "email"}
+//	{"$fake": "int", "min": 1, "max": 9}
+//
+// Values are drawn when the fixture is decoded, so documents cached by
+// WithDocumentCache repeat them.
+func (f *faker) directive() *jwalk.Directive {
+	return jwalk.NewDirective("fake", func(dec *jsontext.Decoder) (exp.Pattern[any], error) {
+		var kind string
+		if err := json.UnmarshalDecode(dec, &kind); err != nil {
+			return exp.Pattern[any]{}, fmt.Errorf("invalid $fake payload: %w", err)
+		}
+		var r fakeRange
+		for dec.PeekKind() == '"' {
+			var key string
+			if err := json.UnmarshalDecode(dec, &key); err != nil {
+				return exp.Pattern[any]{}, err
+			}
+			var bound float64
+			if err := json.UnmarshalDecode(dec, &bound); err != nil {
+				return exp.Pattern[any]{}, fmt.Errorf("invalid $fake %s: %w", key, err)
+			}
+			switch key {
+			case "min":
+				r.min = &bound
+			case "max":
+				r.max = &bound
+			default:
+				return exp.Pattern[any]{}, fmt.Errorf("unknown $fake option %q", key)
+			}
+		}
+		if (r.min != nil || r.max != nil) && kind != "int" && kind != "float" {
+			return exp.Pattern[any]{}, fmt.Errorf("$fake %s does not take min or max", kind)
+		}
+		v, err := f.generate(kind, r)
+		if err != nil {
+			return exp.Pattern[any]{}, err
+		}
+		return exp.Value(v), nil
+	})
+}
+
+func (f *faker) generate(kind string, r fakeRange) (any, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.used = true
+	switch kind {
+	case "firstName":
+		return pick(f.rand, fakeFirstNames), nil
+	case "lastName":
+		return pick(f.rand, fakeLastNames), nil
+	case "name":
+		return pick(f.rand, fakeFirstNames) + " " + pick(f.rand, fakeLastNames), nil
+	case "email":
+		f.seq++ // keeps emails unique for unique indexes
+		return fmt.Sprintf("%s.%s.%d@example.com",
+			strings.ToLower(pick(f.rand, fakeFirstNames)), strings.ToLower(pick(f.rand, fakeLastNames)), f.seq), nil
+	case "word":
+		return pick(f.rand, fakeWords), nil
+	case "sentence":
+		words := make([]string, 4+f.rand.IntN(5))
+		for i := range words {
+			words[i] = pick(f.rand, fakeWords)
+		}
+		s := strings.Join(words, " ")
+		return strings.ToUpper(s[:1]) + s[1:] + ".", nil
+	case "uuid":
+		var b [16]byte
+		for i := range b {
+			b[i] = byte(f.rand.UintN(256))
+		}
+		b[6] = b[6]&0x0f | 0x40 // version 4
+		b[8] = b[8]&0x3f | 0x80 // RFC 4122 variant
+		return fmt.Sprintf("%x-%x-%x-%x-%x", b[0:4], b[4:6], b[6:8], b[8:10], b[10:]), nil
+	case "bool":
+		return f.rand.IntN(2) == 1, nil
+	case "int":
+		lo, hi, err := r.bounds(0, 100)
+		if err != nil {
+			return nil, err
+		}
+		// integers beyond 2^53 are not exact as float64, and wider ranges
+		// would overflow int64
+		if lo != math.Trunc(lo) || hi != math.Trunc(hi) || lo < -maxFakeInt || hi > maxFakeInt {
+			return nil, errors.New("$fake int bounds must be integers within ±2^53")
+		}
+		return float64(int64(lo) + f.rand.Int64N(int64(hi-lo)+1)), nil
+	case "float":
+		lo, hi, err := r.bounds(0, 1)
+		if err != nil {
+			return nil, err
+		}
+		return lo + f.rand.Float64()*(hi-lo), nil
+	default:
+		return nil, fmt.Errorf("unknown $fake kind %q", kind)
+	}
+}
+
+func (r fakeRange) bounds(lo, hi float64) (float64, float64, error) {
+	if r.min != nil {
+		lo = *r.min
+	}
+	if r.max != nil {
+		hi = *r.max
+	}
+	if lo > hi {
+		return 0, 0, fmt.Errorf("$fake min %v is greater than max %v", lo, hi)
+	}
+	return lo, hi, nil
+}
+
+func pick(r *rand.Rand, values []string) string {
+	return values[r.IntN(len(values))]
+}
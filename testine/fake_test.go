@@ -0,0 +1,151 @@
+package testine
+
+import (
+	"regexp"
+	"testing"
+
+	"github.com/calumari/jwalk"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/calumari/poutine"
+	"github.com/calumari/poutine/database/memory"
+	"github.com/calumari/poutine/exp"
+)
+
+func decodeFake(t *testing.T, f *faker, in string) (any, error) {
+	t.Helper()
+	reg, err := jwalk.NewRegistry(jwalk.WithDirective(f.directive()))
+	require.NoError(t, err)
+	var v any
+	err = reg.Unmarshal([]byte(in), &v)
+	if err != nil {
+		return nil, err
+	}
+	return v.(exp.Pattern[any]).Value(), nil
+}
+
+func Test_faker(t *testing.T) {
+	t.Run("same seed generates same values", func(t *testing.T) {
+		in := `[{"$fake": "name"}, {"$fake": "email"}, {"$fake": "uuid"}, {"$fake": "int"}]`
+		a, b := newFaker(42), newFaker(42)
+		for range 3 {
+			va, err := decodeFake(t, a, `{"$fake": "sentence"}`)
+			require.NoError(t, err)
+			vb, err := decodeFake(t, b, `{"$fake": "sentence"}`)
+			require.NoError(t, err)
+			assert.Equal(t, va, vb)
+		}
+		var docA, docB any
+		regA, _ := jwalk.NewRegistry(jwalk.WithDirective(a.directive()))
+		regB, _ := jwalk.NewRegistry(jwalk.WithDirective(b.directive()))
+		require.NoError(t, regA.Unmarshal([]byte(in), &docA))
+		require.NoError(t, regB.Unmarshal([]byte(in), &docB))
+		assert.Equal(t, docA, docB)
+	})
+
+	t.Run("zero seed picks random seed", func(t *testing.T) {
+		assert.NotZero(t, newFaker(0).seed)
+	})
+
+	kinds := map[string]*regexp.Regexp{
+		"firstName": regexp.MustCompile(`^[A-Z][a-z]+$`),
+		"lastName":  regexp.MustCompile(`^[A-Z][a-z]+$`),
+		"name":      regexp.MustCompile(`^[A-Z][a-z]+ [A-Z][a-z]+$`),
+		"email":     regexp.MustCompile(`^[a-z]+\.[a-z]+\.\d+@example\.com$`),
+		"word":      regexp.MustCompile(`^[a-z]+$`),
+		"sentence":  regexp.MustCompile(`^[A-Z][a-z ]+\.$`),
+		"uuid":      regexp.MustCompile(`^[0-9a-f]{8}-[0-9a-f]{4}-4[0-9a-f]{3}-[89ab][0-9a-f]{3}-[0-9a-f]{12}$`),
+	}
+	for kind, re := range kinds {
+		t.Run(kind+" generates string", func(t *testing.T) {
+			v, err := decodeFake(t, newFaker(1), `{"$fake": "`+kind+`"}`)
+			require.NoError(t, err)
+			assert.Regexp(t, re, v)
+		})
+	}
+
+	t.Run("int respects bounds", func(t *testing.T) {
+		f := newFaker(7)
+		seen := map[float64]bool{}
+		for range 200 {
+			v, err := decodeFake(t, f, `{"$fake": "int", "min": 1, "max": 3}`)
+			require.NoError(t, err)
+			seen[v.(float64)] = true
+		}
+		assert.Equal(t, map[float64]bool{1: true, 2: true, 3: true}, seen)
+	})
+
+	t.Run("int widest range succeeds", func(t *testing.T) {
+		v, err := decodeFake(t, newFaker(7), `{"$fake": "int", "min": -9007199254740992, "max": 9007199254740992}`)
+		require.NoError(t, err)
+		assert.InDelta(t, 0, v, 1<<53)
+	})
+
+	t.Run("float respects bounds", func(t *testing.T) {
+		v, err := decodeFake(t, newFaker(7), `{"$fake": "float", "min": 10, "max": 11}`)
+		require.NoError(t, err)
+		assert.GreaterOrEqual(t, v, 10.0)
+		assert.LessOrEqual(t, v, 11.0)
+	})
+
+	t.Run("bool generates bool", func(t *testing.T) {
+		v, err := decodeFake(t, newFaker(7), `{"$fake": "bool"}`)
+		require.NoError(t, err)
+		assert.IsType(t, true, v)
+	})
+
+	errTests := map[string]string{
+		"unknown kind returns error":          `{"$fake": "ssn"}`,
+		"unknown option returns error":        `{"$fake": "int", "step": 2}`,
+		"inverted bounds returns error":       `{"$fake": "int", "min": 5, "max": 1}`,
+		"fractional int bound returns error":  `{"$fake": "int", "max": 1.5}`,
+		"overflowing int range returns error": `{"$fake": "int", "min": -9e18, "max": 9e18}`,
+		"bounds on string kind returns error": `{"$fake": "email", "min": 1}`,
+		"non string kind returns error":       `{"$fake": 1}`,
+	}
+	for name, in := range errTests {
+		t.Run(name, func(t *testing.T) {
+			_, err := decodeFake(t, newFaker(1), in)
+			assert.Error(t, err)
+		})
+	}
+}
+
+func TestT_fake(t *testing.T) {
+	fixture := `{"users": [{"$repeat": 3, "each": {"name": {"$fake": "name"}, "age": {"$fake": "int", "min": 18, "max": 99}}}]}`
+
+	t.Run("seeded helpers load same data succeeds", func(t *testing.T) {
+		path := writeTemp(t, "fake_*.json", fixture)
+		a, err := New(poutine.New(memory.NewDriver()), WithFakeSeed(99))
+		require.NoError(t, err)
+		b, err := New(poutine.New(memory.NewDriver()), WithFakeSeed(99))
+		require.NoError(t, err)
+		assert.Equal(t, a.LoadJSONWith(t, path, nil), b.LoadJSONWith(t, path, nil))
+		assert.Equal(t, uint64(99), a.FakeSeed())
+	})
+
+	t.Run("document cache repeats values and templates draw new ones succeeds", func(t *testing.T) {
+		path := writeTemp(t, "fake_*.json", `{"users": [{"email": {"$fake": "email"}}]}`)
+		pt, _ := newMemoryT(t, WithDocumentCache())
+		assert.Equal(t, pt.LoadJSON(t, path), pt.LoadJSON(t, path))
+		assert.NotEqual(t, pt.LoadJSONWith(t, path, nil), pt.LoadJSONWith(t, path, nil))
+	})
+
+	t.Run("assert failure reports seed", func(t *testing.T) {
+		pt, driver := newMemoryT(t, WithFakeSeed(1234))
+		pt.Seed(t, pt.LoadJSONWith(t, writeTemp(t, "fake_*.json", fixture), nil))
+		driver.Drop("users")
+		ft := &mockTestingT{}
+		pt.Assert(ft, pt.LoadJSON(t, writeTemp(t, "expected_*.json", `{"users": [{"name": "x"}]}`)))
+		assert.Contains(t, ft.fatal, "fake data seed: 1234 (reproduce with -poutine.seed=1234)")
+	})
+
+	t.Run("assert failure without fake data omits seed", func(t *testing.T) {
+		pt, _ := newMemoryT(t)
+		ft := &mockTestingT{}
+		pt.Assert(ft, jwalk.Document{{Key: "users", Value: jwalk.Array{}}})
+		assert.NotEmpty(t, ft.fatal)
+		assert.NotContains(t, ft.fatal, "fake data seed")
+	})
+}
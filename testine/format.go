@@ -0,0 +1,294 @@
+package testine
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"math"
+	"path/filepath"
+	"strings"
+
+	"github.com/go-json-experiment/json/jsontext"
+	"gopkg.in/yaml.v3"
+)
+
+// fixtureFormats maps fixture file extensions to converters producing JSON.
+// Every format is decoded through the same jwalk registry, so directives
+// behave identically regardless of the format.
+var fixtureFormats = map[string]func([]byte) ([]byte, error){
+	".json":  func(data []byte) ([]byte, error) { return data, nil },
+	".jsonc": relaxedToJSON,
+	".json5": relaxedToJSON,
+	".yaml":  yamlToJSON,
+	".yml":   yamlToJSON,
+}
+
+func isFixtureFile(name string) bool {
+	_, ok := fixtureFormats[strings.ToLower(filepath.Ext(name))]
+	return ok
+}
+
+// fixtureJSON converts the content of a fixture file to JSON according to
+// its extension.
+func fixtureJSON(name string, data []byte) ([]byte, error) {
+	convert, ok := fixtureFormats[strings.ToLower(filepath.Ext(name))]
+	if !ok {
+		return nil, errors.New("not a fixture file")
+	}
+	return convert(data)
+}
+
+// relaxedToJSON converts JSONC and part of JSON5 to JSON. It supports line and
+// block comments, trailing commas, object keys of ASCII letters, digits, _ and
+// $ left unquoted, single-quoted strings and the JSON5 string escapes,
+// including line continuations. Other JSON5 extensions, such as hexadecimal
+// numbers, Infinity, NaN, a leading + or a number starting or ending with a
+// decimal point, are left as they are and rejected by the JSON decoder.
+func relaxedToJSON(data []byte) ([]byte, error) {
+	var out bytes.Buffer
+	out.Grow(len(data))
+	for i := 0; i < len(data); {
+		c := data[i]
+		switch {
+		case c == '"' || c == '\'':
+			end, err := copyString(&out, data, i)
+			if err != nil {
+				return nil, err
+			}
+			i = end
+		case c == '/' && i+1 < len(data) && (data[i+1] == '/' || data[i+1] == '*'):
+			end, err := skipComment(data, i)
+			if err != nil {
+				return nil, err
+			}
+			i = end
+		case c == ',':
+			next := skipSpaceAndComments(data, i+1)
+			if next < len(data) && (data[next] == '}' || data[next] == ']') {
+				i++ // trailing comma
+				continue
+			}
+			out.WriteByte(c)
+			i++
+		case isKeyStart(c):
+			end := i + 1
+			for end < len(data) && isKeyPart(data[end]) {
+				end++
+			}
+			if next := skipSpaceAndComments(data, end); next < len(data) && data[next] == ':' {
+				out.WriteByte('"')
+				out.Write(data[i:end])
+				out.WriteByte('"')
+			} else {
+				out.Write(data[i:end])
+			}
+			i = end
+		default:
+			out.WriteByte(c)
+			i++
+		}
+	}
+	return out.Bytes(), nil
+}
+
+// copyString writes the string literal starting at data[start] as a JSON
+// string and returns the offset after it.
+func copyString(out *bytes.Buffer, data []byte, start int) (int, error) {
+	quote := data[start]
+	out.WriteByte('"')
+	for i := start + 1; i < len(data); i++ {
+		c := data[i]
+		switch {
+		case c == '\\' && i+1 < len(data):
+			end, err := copyEscape(out, data, i)
+			if err != nil {
+				return 0, err
+			}
+			i = end - 1
+		case c == quote:
+			out.WriteByte('"')
+			return i + 1, nil
+		case c == '"': // only reachable in single-quoted strings
+			out.WriteString(`\"`)
+		default:
+			out.WriteByte(c)
+		}
+	}
+	return 0, fmt.Errorf("unterminated string at offset %d", start)
+}
+
+// copyEscape writes the escape sequence starting at data[start] as JSON and
+// returns the offset after it. JSON escapes are kept; \x, \v and \0 become
+// \u escapes, a backslash before a line break is dropped with the break, and
+// any other character, such as ', escapes to itself.
+func copyEscape(out *bytes.Buffer, data []byte, start int) (int, error) {
+	i := start + 1
+	switch c := data[i]; c {
+	case '"', '\\', '/', 'b', 'f', 'n', 'r', 't', 'u':
+		out.Write(data[start : i+1])
+	case 'x':
+		if i+2 >= len(data) || !isHex(data[i+1]) || !isHex(data[i+2]) {
+			return 0, fmt.Errorf("invalid \\x escape at offset %d", start)
+		}
+		out.WriteString(`\u00`)
+		out.Write(data[i+1 : i+3])
+		return i + 3, nil
+	case 'v':
+		out.WriteString(`\u000b`)
+	case '0':
+		if i+1 < len(data) && isDigit(data[i+1]) {
+			return 0, fmt.Errorf("invalid \\0 escape followed by a digit at offset %d", start)
+		}
+		out.WriteString(`\u0000`)
+	case '1', '2', '3', '4', '5', '6', '7', '8', '9':
+		return 0, fmt.Errorf("invalid \\%c escape at offset %d", c, start)
+	case '\r':
+		if i+1 < len(data) && data[i+1] == '\n' {
+			return i + 2, nil
+		}
+	case '\n':
+	default:
+		// U+2028 and U+2029 are line breaks in JSON5
+		if bytes.HasPrefix(data[i:], []byte("\u2028")) || bytes.HasPrefix(data[i:], []byte("\u2029")) {
+			return i + 3, nil
+		}
+		out.WriteByte(c)
+	}
+	return i + 1, nil
+}
+
+// skipComment returns the offset after the comment starting at data[start].
+func skipComment(data []byte, start int) (int, error) {
+	if data[start+1] == '/' {
+		if end := bytes.IndexByte(data[start:], '\n'); end >= 0 {
+			return start + end, nil
+		}
+		return len(data), nil
+	}
+	if end := bytes.Index(data[start+2:], []byte("*/")); end >= 0 {
+		return start + 2 + end + 2, nil
+	}
+	return 0, fmt.Errorf("unterminated comment at offset %d", start)
+}
+
+func skipSpaceAndComments(data []byte, i int) int {
+	for i < len(data) {
+		switch c := data[i]; {
+		case c == ' ' || c == '\t' || c == '\n' || c == '\r':
+			i++
+		case c == '/' && i+1 < len(data) && (data[i+1] == '/' || data[i+1] == '*'):
+			end, err := skipComment(data, i)
+			if err != nil {
+				return len(data)
+			}
+			i = end
+		default:
+			return i
+		}
+	}
+	return i
+}
+
+func isDigit(c byte) bool {
+	return c >= '0' && c <= '9'
+}
+
+func isHex(c byte) bool {
+	return isDigit(c) || c >= 'a' && c <= 'f' || c >= 'A' && c <= 'F'
+}
+
+func isKeyStart(c byte) bool {
+	return c == '_' || c == '$' || c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z'
+}
+
+func isKeyPart(c byte) bool {
+	return isKeyStart(c) || isDigit(c)
+}
+
+// yamlToJSON converts a YAML document to JSON, keeping mapping key order.
+func yamlToJSON(data []byte) ([]byte, error) {
+	var root yaml.Node
+	if err := yaml.Unmarshal(data, &root); err != nil {
+		return nil, err
+	}
+	if len(root.Content) == 0 {
+		return nil, errors.New("empty yaml document")
+	}
+	var buf bytes.Buffer
+	enc := jsontext.NewEncoder(&buf)
+	if err := writeYAML(enc, root.Content[0]); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+func writeYAML(enc *jsontext.Encoder, n *yaml.Node) error {
+	switch n.Kind {
+	case yaml.DocumentNode:
+		return writeYAML(enc, n.Content[0])
+	case yaml.AliasNode:
+		return writeYAML(enc, n.Alias)
+	case yaml.MappingNode:
+		if err := enc.WriteToken(jsontext.BeginObject); err != nil {
+			return err
+		}
+		for i := 0; i+1 < len(n.Content); i += 2 {
+			key := n.Content[i]
+			if key.Kind != yaml.ScalarNode {
+				return fmt.Errorf("line %d: mapping keys must be scalars", key.Line)
+			}
+			if key.ShortTag() == "!!merge" {
+				return fmt.Errorf("line %d: merge keys are not supported", key.Line)
+			}
+			if err := enc.WriteToken(jsontext.String(key.Value)); err != nil {
+				return err
+			}
+			if err := writeYAML(enc, n.Content[i+1]); err != nil {
+				return err
+			}
+		}
+		return enc.WriteToken(jsontext.EndObject)
+	case yaml.SequenceNode:
+		if err := enc.WriteToken(jsontext.BeginArray); err != nil {
+			return err
+		}
+		for _, c := range n.Content {
+			if err := writeYAML(enc, c); err != nil {
+				return err
+			}
+		}
+		return enc.WriteToken(jsontext.EndArray)
+	default:
+		return writeYAMLScalar(enc, n)
+	}
+}
+
+func writeYAMLScalar(enc *jsontext.Encoder, n *yaml.Node) error {
+	switch n.ShortTag() {
+	case "!!null":
+		return enc.WriteToken(jsontext.Null)
+	case "!!bool":
+		var b bool
+		if err := n.Decode(&b); err != nil {
+			return err
+		}
+		return enc.WriteToken(jsontext.Bool(b))
+	case "!!int":
+		var i int64
+		if err := n.Decode(&i); err != nil {
+			return err
+		}
+		return enc.WriteToken(jsontext.Int(i))
+	case "!!float":
+		var f float64
+		if err := n.Decode(&f); err != nil {
+			return err
+		}
+		if math.IsInf(f, 0) || math.IsNaN(f) {
+			return fmt.Errorf("line %d: %s cannot be represented in JSON", n.Line, n.Value)
+		}
+		return enc.WriteToken(jsontext.Float(f))
+	default:
+		return enc.WriteToken(jsontext.String(n.Value))
+	}
+}
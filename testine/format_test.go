@@ -0,0 +1,158 @@
+package testine
+
+import (
+	"testing"
+
+	"github.com/calumari/jwalk"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/calumari/poutine/exp"
+)
+
+func Test_relaxedToJSON(t *testing.T) {
+	tests := []struct {
+		name string
+		in   string
+		want string
+	}{
+		{"plain json is unchanged", `{"a": [1, 2]}`, `{"a": [1, 2]}`},
+		{"line comment is removed", "{\"a\": 1 // one\n}", "{\"a\": 1 \n}"},
+		{"block comment is removed", `{/* note */"a": 1}`, `{"a": 1}`},
+		{"comment markers in strings are kept", `{"url": "http://x/*y*/"}`, `{"url": "http://x/*y*/"}`},
+		{"trailing commas are removed", `{"a": [1, 2,], "b": 3, }`, `{"a": [1, 2], "b": 3 }`},
+		{"trailing comma before comment is removed", "[1, // last\n]", "[1 \n]"},
+		{"unquoted keys are quoted", `{a: 1, $oid: true}`, `{"a": 1, "$oid": true}`},
+		{"literals are kept", `{a: true, b: null}`, `{"a": true, "b": null}`},
+		{"single quoted strings are converted", `{'a': 'it\'s "x"'}`, `{"a": "it's \"x\""}`},
+		{"escaped single quote in double quoted string is unescaped", `{"a": "it\'s"}`, `{"a": "it's"}`},
+		{"json escapes are kept", `{a: 'tab\t\u00e9\\'}`, `{"a": "tab\t\u00e9\\"}`},
+		{"hex escape becomes unicode escape", `{a: '\x41'}`, `{"a": "\u0041"}`},
+		{"vertical tab and nul escapes become unicode escapes", `{a: '\v\0'}`, `{"a": "\u000b\u0000"}`},
+		{"line continuation is removed", "{a: 'one \\\ntwo \\\r\nthree'}", `{"a": "one two three"}`},
+		{"other escaped characters are unescaped", `{a: '\a\%'}`, `{"a": "a%"}`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := relaxedToJSON([]byte(tt.in))
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, string(got))
+		})
+	}
+
+	t.Run("unterminated string returns error", func(t *testing.T) {
+		_, err := relaxedToJSON([]byte(`{"a": 'x}`))
+		assert.Error(t, err)
+	})
+
+	t.Run("invalid escapes return positioned error", func(t *testing.T) {
+		for in, want := range map[string]string{
+			`{a: '\x4'}`: `invalid \x escape at offset 5`,
+			`{a: '\01'}`: `invalid \0 escape followed by a digit at offset 5`,
+			`{a: '\1'}`:  `invalid \1 escape at offset 5`,
+		} {
+			_, err := relaxedToJSON([]byte(in))
+			assert.EqualError(t, err, want, in)
+		}
+	})
+
+	t.Run("unterminated comment returns error", func(t *testing.T) {
+		_, err := relaxedToJSON([]byte(`{"a": 1 /* x}`))
+		assert.Error(t, err)
+	})
+}
+
+func Test_yamlToJSON(t *testing.T) {
+	tests := []struct {
+		name string
+		in   string
+		want string
+	}{
+		{"mapping keeps key order", "b: 1\na: 2\n", `{"b":1,"a":2}`},
+		{"scalars keep their types", "s: x\ni: 3\nf: 1.5\nb: true\nn: null\nq: \"3\"\n", `{"s":"x","i":3,"f":1.5,"b":true,"n":null,"q":"3"}`},
+		{"sequences become arrays", "- 1\n- [a, b]\n", `[1,["a","b"]]`},
+		{"flow mapping with directive", "_id: {$oid: true}\n", `{"_id":{"$oid":true}}`},
+		{"aliases are expanded", "a: &x {n: 1}\nb: *x\n", `{"a":{"n":1},"b":{"n":1}}`},
+		{"timestamps stay strings", "t: 2024-01-01T00:00:00Z\n", `{"t":"2024-01-01T00:00:00Z"}`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := yamlToJSON([]byte(tt.in))
+			require.NoError(t, err)
+			assert.Equal(t, tt.want+"\n", string(got))
+		})
+	}
+
+	invalid := []struct {
+		name string
+		in   string
+	}{
+		{"empty document returns error", ""},
+		{"invalid yaml returns error", "a: [1\n"},
+		{"merge key returns error", "a: &x {n: 1}\nb:\n  <<: *x\n"},
+		{"non scalar key returns error", "? [a]\n: 1\n"},
+		{"infinity returns error", "a: .inf\n"},
+	}
+	for _, tt := range invalid {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := yamlToJSON([]byte(tt.in))
+			assert.Error(t, err)
+		})
+	}
+}
+
+func Test_documentLoader_formats(t *testing.T) {
+	newLoader := func(t *testing.T) *documentLoader {
+		reg, err := jwalk.NewRegistry()
+		require.NoError(t, err)
+		require.NoError(t, exp.RegisterMatchers(reg))
+		return newDocumentLoader(reg, false, MergeReplace)
+	}
+	want, err := newLoader(t).load(writeTemp(t, "doc_*.json", `{"users": [{"name": {"$regex": "^A"}, "age": 30}]}`))
+	require.NoError(t, err)
+
+	formats := []struct {
+		name    string
+		pattern string
+		content string
+	}{
+		{"yaml fixture succeeds", "doc_*.yaml", "users:\n  - name: {$regex: ^A}\n    age: 30\n"},
+		{"yml fixture succeeds", "doc_*.yml", "users: [{name: {$regex: ^A}, age: 30}]\n"},
+		{"jsonc fixture succeeds", "doc_*.jsonc", "{\n  // users\n  \"users\": [{\"name\": {\"$regex\": \"^A\"}, \"age\": 30},],\n}"},
+		{"json5 fixture succeeds", "doc_*.json5", "{users: [{name: {$regex: '^A'}, age: 30}]}"},
+	}
+	for _, tt := range formats {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := newLoader(t).load(writeTemp(t, tt.pattern, tt.content))
+			require.NoError(t, err)
+			assert.Equal(t, want, got)
+		})
+	}
+
+	t.Run("directory merges every format succeeds", func(t *testing.T) {
+		dir := writeDirFiles(t, map[string]string{
+			"a.json":  `{"a": 1}`,
+			"b.yaml":  "b: 2\n",
+			"c.jsonc": `{"c": 3, /* trailing */}`,
+			"d.txt":   "ignored",
+		})
+		got, err := newLoader(t).load(dir)
+		require.NoError(t, err)
+		assert.Equal(t, jwalk.Document{{Key: "a", Value: 1.0}, {Key: "b", Value: 2.0}, {Key: "c", Value: 3.0}}, got)
+	})
+
+	t.Run("template over yaml fixture succeeds", func(t *testing.T) {
+		path := writeTemp(t, "doc_*.yaml", "users:\n  - $repeat: ${n}\n    each: {id: \"${i}\"}\n")
+		got, err := newLoader(t).loadTemplate(path, newTemplate(map[string]any{"n": 2}, nil))
+		require.NoError(t, err)
+		assert.Equal(t, jwalk.Document{{Key: "users", Value: jwalk.Array{
+			jwalk.Document{{Key: "id", Value: 0.0}},
+			jwalk.Document{{Key: "id", Value: 1.0}},
+		}}}, got)
+	})
+
+	t.Run("invalid yaml fixture returns error", func(t *testing.T) {
+		_, err := newLoader(t).load(writeTemp(t, "doc_*.yaml", "a: [1\n"))
+		assert.Error(t, err)
+	})
+}
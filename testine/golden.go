@@ -0,0 +1,181 @@
+package testine
+
+import (
+	"bytes"
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/calumari/jwalk"
+	"github.com/go-json-experiment/json"
+	"github.com/go-json-experiment/json/jsontext"
+
+	"github.com/calumari/poutine/exp"
+)
+
+var updateFlag = flag.Bool("poutine.update", false, "rewrite JSON fixtures passed to testine Assert with the actual snapshot")
+
+// rawNode is a parsed JSON fixture that keeps the original encoding of every
+// value so unchanged parts of a golden file, including directives, can be
+// written back verbatim.
+type rawNode struct {
+	value   jsontext.Value
+	keys    []string
+	members []*rawNode
+	elems   []*rawNode
+}
+
+func parseRaw(v jsontext.Value) (*rawNode, error) {
+	n := &rawNode{value: v}
+	kind := v.Kind()
+	var end jsontext.Kind
+	switch kind {
+	case '{':
+		end = '}'
+	case '[':
+		end = ']'
+	default:
+		return n, nil
+	}
+	dec := jsontext.NewDecoder(bytes.NewReader(v))
+	if _, err := dec.ReadToken(); err != nil {
+		return nil, err
+	}
+	for dec.PeekKind() != end {
+		var key string
+		if kind == '{' {
+			tok, err := dec.ReadToken()
+			if err != nil {
+				return nil, err
+			}
+			key = tok.String()
+		}
+		val, err := dec.ReadValue()
+		if err != nil {
+			return nil, err
+		}
+		child, err := parseRaw(val.Clone())
+		if err != nil {
+			return nil, err
+		}
+		if kind == '{' {
+			n.keys = append(n.keys, key)
+			n.members = append(n.members, child)
+		} else {
+			n.elems = append(n.elems, child)
+		}
+	}
+	return n, nil
+}
+
+func (n *rawNode) member(key string) *rawNode {
+	for i, k := range n.keys {
+		if k == key {
+			return n.members[i]
+		}
+	}
+	return nil
+}
+
+// goldenWriter encodes an actual snapshot in the shape of the fixture it was
+// compared against. Values that still satisfy the expected fixture keep their
+// original encoding; everything else is replaced by the actual value.
+type goldenWriter struct {
+	tester     Tester
+	marshalers *json.Marshalers
+}
+
+func (w *goldenWriter) write(enc *jsontext.Encoder, raw *rawNode, expected, actual any) error {
+	if raw == nil {
+		return encodeValue(enc, actual, w.marshalers)
+	}
+	switch want := expected.(type) {
+	case jwalk.Document:
+		act, ok := actual.(jwalk.Document)
+		if !ok || raw.value.Kind() != '{' {
+			break
+		}
+		if err := enc.WriteToken(jsontext.BeginObject); err != nil {
+			return err
+		}
+		for i, key := range raw.keys {
+			av, ok := lookup(act, key)
+			ev, _ := lookup(want, key)
+			if !ok && !exp.IsAbsent(ev) {
+				continue
+			}
+			if err := enc.WriteToken(jsontext.String(key)); err != nil {
+				return err
+			}
+			if !ok {
+				if err := enc.WriteValue(raw.members[i].value); err != nil {
+					return err
+				}
+				continue
+			}
+			if err := w.write(enc, raw.members[i], ev, av); err != nil {
+				return err
+			}
+		}
+		for _, e := range act {
+			if raw.member(e.Key) != nil {
+				continue
+			}
+			if err := enc.WriteToken(jsontext.String(e.Key)); err != nil {
+				return err
+			}
+			if err := encodeValue(enc, e.Value, w.marshalers); err != nil {
+				return err
+			}
+		}
+		return enc.WriteToken(jsontext.EndObject)
+	case jwalk.Array:
+		act, ok := actual.(jwalk.Array)
+		if !ok || raw.value.Kind() != '[' {
+			break
+		}
+		if err := enc.WriteToken(jsontext.BeginArray); err != nil {
+			return err
+		}
+		for i, av := range act {
+			var (
+				child *rawNode
+				ev    any
+			)
+			if i < len(raw.elems) && i < len(want) {
+				child, ev = raw.elems[i], want[i]
+			}
+			if err := w.write(enc, child, ev, av); err != nil {
+				return err
+			}
+		}
+		return enc.WriteToken(jsontext.EndArray)
+	}
+	if w.tester.Test(expected, actual) == nil {
+		return enc.WriteValue(raw.value)
+	}
+	return encodeValue(enc, actual, w.marshalers)
+}
+
+// updateGolden rewrites the fixture at path with the actual snapshot.
+func (pt *T) updateGolden(path string, expected, actual jwalk.Document) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	raw, err := parseRaw(jsontext.Value(data))
+	if err != nil {
+		return fmt.Errorf("parse fixture: %w", err)
+	}
+	var buf bytes.Buffer
+	enc := newFixtureEncoder(&buf)
+	w := &goldenWriter{tester: pt.tester, marshalers: pt.marshalers}
+	if err := w.write(enc, raw, expected, actual); err != nil {
+		return fmt.Errorf("encode snapshot: %w", err)
+	}
+	if err := os.WriteFile(path, buf.Bytes(), 0o644); err != nil {
+		return err
+	}
+	pt.loader.invalidate()
+	return nil
+}
@@ -0,0 +1,205 @@
+package testine
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+
+	"github.com/calumari/jwalk"
+	"github.com/go-json-experiment/json/jsontext"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/calumari/poutine"
+	"github.com/calumari/poutine/database/memory"
+	"github.com/calumari/poutine/exp"
+)
+
+var idDirective = jwalk.NewDirective("id", func(dec *jsontext.Decoder) (exp.Pattern[string], error) {
+	v, err := dec.ReadValue()
+	if err != nil {
+		return exp.Pattern[string]{}, err
+	}
+	if v.Kind() == 't' {
+		return exp.Any("generated"), nil
+	}
+	s := string(v[1 : len(v)-1])
+	if ref, ok := exp.ParseRef(s, "generated"); ok {
+		return ref, nil
+	}
+	return exp.Value(s), nil
+})
+
+func newGoldenT(t *testing.T, driver *memory.Driver, opts ...Option) *T {
+	t.Helper()
+	reg, err := jwalk.NewRegistry(jwalk.WithDirective(idDirective))
+	require.NoError(t, err)
+	pt, err := New(poutine.New(driver), append([]Option{WithRegistry(reg), WithColor(false)}, opts...)...)
+	require.NoError(t, err)
+	return pt
+}
+
+func TestT_Assert_update(t *testing.T) {
+	const fixture = `{
+  "pets": [
+    {
+      "id": {"$id": true},
+      "name": "Luna"
+    }
+  ]
+}
+`
+	seed := jwalk.Document{{Key: "pets", Value: jwalk.Array{
+		jwalk.Document{{Key: "id", Value: exp.Any("generated")}, {Key: "name", Value: "Luna"}},
+	}}}
+
+	t.Run("update mode rewrites fixture preserving directives", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "after.json")
+		require.NoError(t, os.WriteFile(path, []byte(fixture), 0o644))
+		driver := memory.NewDriver()
+		pt := newGoldenT(t, driver, WithUpdate())
+		pt.Seed(t, seed)
+		driver.Mutate("pets", func(docs jwalk.Array) jwalk.Array {
+			docs[0].(jwalk.Document)[1].Value = "Max"
+			return docs
+		})
+		driver.Insert("pets", jwalk.Document{{Key: "id", Value: "p2"}, {Key: "name", Value: "Bella"}, {Key: "age", Value: 3}})
+
+		ft := &mockTestingT{}
+		pt.Assert(ft, pt.LoadJSON(t, path))
+		assert.Empty(t, ft.fatal)
+		assert.Equal(t, []string{"updated " + path + " with the actual snapshot"}, ft.logs)
+
+		got, err := os.ReadFile(path)
+		require.NoError(t, err)
+		want := `{
+  "pets": [
+    {
+      "id": {
+        "$id": true
+      },
+      "name": "Max"
+    },
+    {
+      "id": "p2",
+      "name": "Bella",
+      "age": 3
+    }
+  ]
+}
+`
+		assert.Equal(t, want, string(got))
+		pt.Assert(t, pt.LoadJSON(t, path))
+	})
+
+	t.Run("update mode with cache reloads rewritten fixture", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "after.json")
+		require.NoError(t, os.WriteFile(path, []byte(fixture), 0o644))
+		driver := memory.NewDriver()
+		pt := newGoldenT(t, driver, WithUpdate(), WithDocumentCache())
+		pt.Seed(t, seed)
+		pt.LoadJSON(t, path)
+		driver.Insert("pets", jwalk.Document{{Key: "id", Value: "p2"}, {Key: "name", Value: "Bella"}})
+
+		pt.Assert(t, pt.LoadJSON(t, path))
+		doc := pt.LoadJSON(t, path)
+		pets, _ := lookup(doc, "pets")
+		assert.Len(t, pets, 2)
+	})
+
+	t.Run("update mode passing assertion leaves fixture untouched", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "after.json")
+		require.NoError(t, os.WriteFile(path, []byte(fixture), 0o644))
+		pt := newGoldenT(t, memory.NewDriver(), WithUpdate())
+		pt.Seed(t, seed)
+
+		pt.Assert(t, pt.LoadJSON(t, path))
+		got, err := os.ReadFile(path)
+		require.NoError(t, err)
+		assert.Equal(t, fixture, string(got))
+	})
+
+	t.Run("without update mode mismatch returns fatal", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "after.json")
+		require.NoError(t, os.WriteFile(path, []byte(fixture), 0o644))
+		driver := memory.NewDriver()
+		pt := newGoldenT(t, driver)
+		pt.Seed(t, seed)
+		driver.Drop("pets")
+
+		ft := &mockTestingT{}
+		pt.Assert(ft, pt.LoadJSON(t, path))
+		assert.Contains(t, ft.fatal, "assert:")
+		got, err := os.ReadFile(path)
+		require.NoError(t, err)
+		assert.Equal(t, fixture, string(got))
+	})
+
+	t.Run("update mode copied document rewrites fixture", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "after.json")
+		require.NoError(t, os.WriteFile(path, []byte(fixture), 0o644))
+		driver := memory.NewDriver()
+		pt := newGoldenT(t, driver, WithUpdate())
+		pt.Seed(t, seed)
+		driver.Mutate("pets", func(jwalk.Array) jwalk.Array { return jwalk.Array{} })
+
+		ft := &mockTestingT{}
+		pt.Assert(ft, slices.Clone(pt.LoadJSON(t, path)))
+		assert.Empty(t, ft.fatal)
+		got, err := os.ReadFile(path)
+		require.NoError(t, err)
+		assert.Equal(t, "{\n  \"pets\": []\n}\n", string(got))
+	})
+
+	t.Run("update mode does not bind captures of rewritten assertion", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "after.json")
+		require.NoError(t, os.WriteFile(path, []byte(`{"pets": [{"id": {"$id": "?petId"}, "name": "Max"}]}`), 0o644))
+		pt := newGoldenT(t, memory.NewDriver(), WithUpdate())
+		pt.Seed(t, seed)
+
+		ft := &mockTestingT{}
+		got := pt.Assert(ft, pt.LoadJSON(t, path))
+		assert.Empty(t, ft.fatal)
+		assert.Len(t, ft.logs, 1)
+		assert.Empty(t, got)
+	})
+
+	t.Run("update mode forgets sources of completed tests", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "after.json")
+		require.NoError(t, os.WriteFile(path, []byte(fixture), 0o644))
+		pt := newGoldenT(t, memory.NewDriver(), WithUpdate())
+		t.Run("load", func(t *testing.T) {
+			pt.LoadJSON(t, path)
+			assert.Len(t, pt.sources, 1)
+		})
+		assert.Empty(t, pt.sources)
+	})
+
+	t.Run("update mode document not loaded from file returns fatal", func(t *testing.T) {
+		driver := memory.NewDriver()
+		pt := newGoldenT(t, driver, WithUpdate())
+		pt.Seed(t, seed)
+
+		ft := &mockTestingT{}
+		pt.Assert(ft, docKV("pets", jwalk.Array{}))
+		assert.Contains(t, ft.fatal, "assert:")
+	})
+}
+
+func Test_parseRaw(t *testing.T) {
+	t.Run("parses nested values preserving raw encoding", func(t *testing.T) {
+		n, err := parseRaw(jsontext.Value(`{"a": [1, {"$id": true}], "b": "x"}`))
+		require.NoError(t, err)
+		assert.Equal(t, []string{"a", "b"}, n.keys)
+		require.Len(t, n.members[0].elems, 2)
+		assert.Equal(t, `{"$id": true}`, string(n.members[0].elems[1].value))
+		assert.Equal(t, `"x"`, string(n.member("b").value))
+		assert.Nil(t, n.member("c"))
+	})
+
+	t.Run("invalid json returns error", func(t *testing.T) {
+		_, err := parseRaw(jsontext.Value(`{"a": }`))
+		assert.Error(t, err)
+	})
+}
@@ -1,7 +1,10 @@
 package testine
 
 import (
+	"bytes"
 	"errors"
+	"fmt"
+	"io"
 	"os"
 	"path/filepath"
 	"sort"
@@ -16,6 +19,7 @@ import (
 type documentLoader struct {
 	reg   *jwalk.Registry
 	cache bool
+	merge MergeStrategy
 
 	mu        sync.RWMutex
 	fileCache map[string]jwalk.Document
@@ -24,8 +28,8 @@ type documentLoader struct {
 	group singleflight.Group
 }
 
-func newDocumentLoader(reg *jwalk.Registry, cache bool) *documentLoader {
-	fl := &documentLoader{reg: reg, cache: cache}
+func newDocumentLoader(reg *jwalk.Registry, cache bool, merge MergeStrategy) *documentLoader {
+	fl := &documentLoader{reg: reg, cache: cache, merge: merge}
 	if cache {
 		fl.fileCache = make(map[string]jwalk.Document)
 		fl.pathCache = make(map[string]jwalk.Document)
@@ -58,6 +62,18 @@ func (l *documentLoader) load(path string) (jwalk.Document, error) {
 	return v.(jwalk.Document), nil
 }
 
+// invalidate drops every cached document after a fixture file was rewritten:
+// directories, globs and composed files may include it.
+func (l *documentLoader) invalidate() {
+	if !l.cache {
+		return
+	}
+	l.mu.Lock()
+	clear(l.fileCache)
+	clear(l.pathCache)
+	l.mu.Unlock()
+}
+
 func (l *documentLoader) getPathCache(path string) jwalk.Document {
 	l.mu.RLock()
 	defer l.mu.RUnlock()
@@ -71,43 +87,60 @@ func (l *documentLoader) setPathCache(path string, doc jwalk.Document) {
 }
 
 func (l *documentLoader) loadPath(path string) (jwalk.Document, error) {
+	return l.loadFiles(path, l.getFile)
+}
+
+// loadTemplate loads path like load, expanding each file, including the files
+// it includes or extends, with tp before decoding. Expanded documents are never
+// cached.
+func (l *documentLoader) loadTemplate(path string, tp *template) (jwalk.Document, error) {
+	expand := func(file string) (jwalk.Document, error) {
+		data, err := readFixture(file)
+		if err != nil {
+			return nil, err
+		}
+		data, err = tp.expand(data)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", file, err)
+		}
+		return l.decode(bytes.NewReader(data))
+	}
+	return l.loadFiles(path, func(file string) (jwalk.Document, error) {
+		return l.compose(file, expand, nil)
+	})
+}
+
+// loadFiles resolves path and merges the documents read by getFile with the
+// loader's merge strategy.
+func (l *documentLoader) loadFiles(path string, getFile func(string) (jwalk.Document, error)) (jwalk.Document, error) {
 	files, err := l.resolve(path)
 	if err != nil {
 		return nil, err
 	}
 	if len(files) == 0 {
-		return nil, errors.New("no json files resolved")
+		return nil, errors.New("no fixture files resolved")
 	}
 	if len(files) == 1 {
-		return l.getFile(files[0])
+		return getFile(files[0])
 	}
 	sort.Strings(files)
-	var readErr error
-	doc := mergeDocs(func(yield func(jwalk.Document)) {
-		for _, f := range files {
-			if readErr != nil {
-				return
-			}
-			d, err := l.getFile(f)
-			if err != nil {
-				readErr = err
-				return
-			}
-			yield(d)
+	docs := make([]sourcedDoc, 0, len(files))
+	for _, f := range files {
+		d, err := getFile(f)
+		if err != nil {
+			return nil, err
 		}
-	})
-	if readErr != nil {
-		return nil, readErr
+		docs = append(docs, sourcedDoc{file: f, doc: d})
 	}
-	return doc, nil
+	return l.merge.merge(docs)
 }
 
 func (l *documentLoader) resolve(path string) ([]string, error) {
 	info, err := os.Stat(path)
 	if err == nil {
 		if !info.IsDir() {
-			if !isJSONFile(path) {
-				return nil, errors.New("not a json file")
+			if !isFixtureFile(path) {
+				return nil, errors.New("not a fixture file")
 			}
 			return []string{path}, nil
 		}
@@ -121,7 +154,7 @@ func (l *documentLoader) resolve(path string) ([]string, error) {
 				continue
 			}
 			name := e.Name()
-			if isJSONFile(name) {
+			if isFixtureFile(name) {
 				out = append(out, filepath.Join(path, name))
 			}
 		}
@@ -136,7 +169,7 @@ func (l *documentLoader) resolve(path string) ([]string, error) {
 	}
 	var out []string
 	for _, m := range matches {
-		if info, err := os.Stat(m); err == nil && !info.IsDir() && isJSONFile(m) {
+		if info, err := os.Stat(m); err == nil && !info.IsDir() && isFixtureFile(m) {
 			out = append(out, m)
 		}
 	}
@@ -180,13 +213,33 @@ func (l *documentLoader) setFileCache(f string, d jwalk.Document) {
 }
 
 func (l *documentLoader) readFile(file string) (jwalk.Document, error) {
-	f, err := os.Open(file)
+	return l.compose(file, l.decodeFile, nil)
+}
+
+func (l *documentLoader) decodeFile(file string) (jwalk.Document, error) {
+	data, err := readFixture(file)
+	if err != nil {
+		return nil, err
+	}
+	return l.decode(bytes.NewReader(data))
+}
+
+// readFixture reads file and converts it to JSON according to its extension.
+func readFixture(file string) ([]byte, error) {
+	data, err := os.ReadFile(file)
 	if err != nil {
 		return nil, err
 	}
-	defer f.Close()
+	data, err = fixtureJSON(file, data)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", file, err)
+	}
+	return data, nil
+}
+
+func (l *documentLoader) decode(r io.Reader) (jwalk.Document, error) {
 	var doc jwalk.Document
-	if err := json.UnmarshalRead(f, &doc, json.WithUnmarshalers(jwalk.Unmarshalers(l.reg))); err != nil {
+	if err := json.UnmarshalRead(r, &doc, json.WithUnmarshalers(jwalk.Unmarshalers(l.reg))); err != nil {
 		return nil, err
 	}
 	return doc, nil
@@ -200,10 +253,6 @@ func copyDoc(in jwalk.Document) jwalk.Document {
 	return cp
 }
 
-func isJSONFile(name string) bool {
-	return strings.HasSuffix(strings.ToLower(name), ".json")
-}
-
 func mergeDocs(iter func(func(jwalk.Document))) jwalk.Document {
 	var merged jwalk.Document
 	index := make(map[string]int)
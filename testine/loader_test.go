@@ -14,7 +14,7 @@ import (
 
 func setupLoader(cache bool) *documentLoader {
 	reg := &jwalk.Registry{}
-	return newDocumentLoader(reg, cache)
+	return newDocumentLoader(reg, cache, MergeReplace)
 }
 
 // helpers
@@ -0,0 +1,153 @@
+package testine
+
+import (
+	"fmt"
+	"reflect"
+	"slices"
+
+	"github.com/calumari/jwalk"
+)
+
+// MergeStrategy controls how fixtures loaded together from a directory, glob
+// or $include list are combined when several of them define the same
+// collection. Files are merged in lexical order.
+type MergeStrategy int
+
+const (
+	// MergeReplace keeps the collection of the last file defining it.
+	MergeReplace MergeStrategy = iota
+	// MergeAppend concatenates the documents of every file. Documents with
+	// the same _id are reported as a conflict.
+	MergeAppend
+	// MergeByID deep-merges documents with the same _id, values of later
+	// files winning, and appends the others.
+	MergeByID
+	// MergeError reports a collection defined by more than one file.
+	MergeError
+)
+
+func (s MergeStrategy) String() string {
+	switch s {
+	case MergeReplace:
+		return "replace"
+	case MergeAppend:
+		return "append"
+	case MergeByID:
+		return "merge by _id"
+	case MergeError:
+		return "error"
+	default:
+		return fmt.Sprintf("MergeStrategy(%d)", int(s))
+	}
+}
+
+// WithMergeStrategy sets how LoadJSON merges multi-file fixtures. The default
+// is MergeReplace.
+func WithMergeStrategy(s MergeStrategy) Option {
+	return func(o *Options) { o.merge = s }
+}
+
+// sourcedDoc is a fixture document with the file it was read from.
+type sourcedDoc struct {
+	file string
+	doc  jwalk.Document
+}
+
+// merge combines docs according to s. Conflicts name the files that
+// contributed the conflicting collections or documents.
+func (s MergeStrategy) merge(docs []sourcedDoc) (jwalk.Document, error) {
+	if s == MergeReplace {
+		return mergeDocs(func(yield func(jwalk.Document)) {
+			for _, d := range docs {
+				yield(d.doc)
+			}
+		}), nil
+	}
+	var merged jwalk.Document
+	index := make(map[string]int)
+	definedBy := make(map[string]string) // collection -> first file
+	owners := make(map[string][]string)  // collection -> file of each document
+	for _, d := range docs {
+		for _, e := range d.doc {
+			pos, ok := index[e.Key]
+			if !ok {
+				index[e.Key] = len(merged)
+				merged = append(merged, e)
+				definedBy[e.Key] = d.file
+				owners[e.Key] = ownedBy(d.file, e.Value)
+				continue
+			}
+			if s == MergeError {
+				return nil, fmt.Errorf("collection %q is defined by %s and %s", e.Key, definedBy[e.Key], d.file)
+			}
+			prev, ok1 := merged[pos].Value.(jwalk.Array)
+			next, ok2 := e.Value.(jwalk.Array)
+			if !ok1 || !ok2 {
+				return nil, fmt.Errorf("collection %q: cannot merge %T from %s with %T from %s",
+					e.Key, merged[pos].Value, definedBy[e.Key], e.Value, d.file)
+			}
+			coll, files, err := s.mergeCollection(prev, owners[e.Key], next, d.file)
+			if err != nil {
+				return nil, fmt.Errorf("collection %q: %w", e.Key, err)
+			}
+			merged[pos].Value = coll
+			owners[e.Key] = files
+		}
+	}
+	return merged, nil
+}
+
+// mergeCollection adds the documents of next, read from file, to prev whose
+// documents were read from files.
+func (s MergeStrategy) mergeCollection(prev jwalk.Array, files []string, next jwalk.Array, file string) (jwalk.Array, []string, error) {
+	out := slices.Clone(prev)
+	files = slices.Clone(files)
+	for _, v := range next {
+		i := -1
+		if id, ok := documentKey(v, idKey); ok {
+			i = slices.IndexFunc(out, func(b any) bool {
+				bid, ok := documentKey(b, idKey)
+				return ok && reflect.DeepEqual(bid, id)
+			})
+			if i >= 0 && s == MergeAppend {
+				return nil, nil, fmt.Errorf("document with %s %v from %s conflicts with the one from %s", idKey, id, file, files[i])
+			}
+		}
+		if i < 0 {
+			out = append(out, v)
+			files = append(files, file)
+			continue
+		}
+		out[i] = deepMerge(out[i], v)
+	}
+	return out, files, nil
+}
+
+// deepMerge merges override into base: documents are merged field by field,
+// recursively, and any other value of override replaces the base value.
+func deepMerge(base, override any) any {
+	b, ok1 := base.(jwalk.Document)
+	o, ok2 := override.(jwalk.Document)
+	if !ok1 || !ok2 {
+		return override
+	}
+	out := copyDoc(b)
+	for _, e := range o {
+		if i := slices.IndexFunc(out, func(f jwalk.Entry) bool { return f.Key == e.Key }); i >= 0 {
+			out[i].Value = deepMerge(out[i].Value, e.Value)
+			continue
+		}
+		out = append(out, e)
+	}
+	return out
+}
+
+// ownedBy returns the file of each document of a newly merged collection.
+func ownedBy(file string, v any) []string {
+	arr, _ := v.(jwalk.Array)
+	files := make([]string, len(arr))
+	for i := range files {
+		files[i] = file
+	}
+	return files
+}
@@ -0,0 +1,126 @@
+package testine
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/calumari/jwalk"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/calumari/poutine/exp"
+)
+
+func Test_MergeStrategy_merge(t *testing.T) {
+	files := map[string]string{
+		"a.json": `{"users": [{"_id": 1, "name": "alice", "address": {"city": "Paris", "zip": "75001"}}, {"name": "anon"}], "tenants": [{"_id": 1}]}`,
+		"b.json": `{"users": [{"_id": 1, "address": {"city": "Lyon"}}, {"_id": 2, "name": "bob"}]}`,
+	}
+	load := func(t *testing.T, s MergeStrategy) (jwalk.Document, error) {
+		t.Helper()
+		return newDocumentLoader(&jwalk.Registry{}, false, s).load(writeDirFiles(t, files))
+	}
+	tenants := jwalk.Entry{Key: "tenants", Value: jwalk.Array{jwalk.Document{{Key: "_id", Value: 1.0}}}}
+
+	t.Run("replace keeps last collection succeeds", func(t *testing.T) {
+		got, err := load(t, MergeReplace)
+		require.NoError(t, err)
+		assert.Equal(t, jwalk.Document{
+			{Key: "users", Value: jwalk.Array{
+				jwalk.Document{{Key: "_id", Value: 1.0}, {Key: "address", Value: jwalk.Document{{Key: "city", Value: "Lyon"}}}},
+				jwalk.Document{{Key: "_id", Value: 2.0}, {Key: "name", Value: "bob"}},
+			}},
+			tenants,
+		}, got)
+	})
+
+	t.Run("merge by id deep merges documents succeeds", func(t *testing.T) {
+		got, err := load(t, MergeByID)
+		require.NoError(t, err)
+		assert.Equal(t, jwalk.Document{
+			{Key: "users", Value: jwalk.Array{
+				jwalk.Document{
+					{Key: "_id", Value: 1.0},
+					{Key: "name", Value: "alice"},
+					{Key: "address", Value: jwalk.Document{{Key: "city", Value: "Lyon"}, {Key: "zip", Value: "75001"}}},
+				},
+				jwalk.Document{{Key: "name", Value: "anon"}},
+				jwalk.Document{{Key: "_id", Value: 2.0}, {Key: "name", Value: "bob"}},
+			}},
+			tenants,
+		}, got)
+	})
+
+	t.Run("append concatenates documents succeeds", func(t *testing.T) {
+		got, err := newDocumentLoader(&jwalk.Registry{}, false, MergeAppend).load(writeDirFiles(t, map[string]string{
+			"a.json": `{"users": [{"_id": 1}, {"name": "anon"}]}`,
+			"b.json": `{"users": [{"_id": 2}, {"name": "anon"}]}`,
+		}))
+		require.NoError(t, err)
+		assert.Equal(t, jwalk.Document{{Key: "users", Value: jwalk.Array{
+			jwalk.Document{{Key: "_id", Value: 1.0}},
+			jwalk.Document{{Key: "name", Value: "anon"}},
+			jwalk.Document{{Key: "_id", Value: 2.0}},
+			jwalk.Document{{Key: "name", Value: "anon"}},
+		}}}, got)
+	})
+
+	t.Run("append with duplicate id returns error naming files", func(t *testing.T) {
+		dir := writeDirFiles(t, files)
+		_, err := newDocumentLoader(&jwalk.Registry{}, false, MergeAppend).load(dir)
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), `collection "users"`)
+		assert.Contains(t, err.Error(), filepath.Join(dir, "a.json"))
+		assert.Contains(t, err.Error(), filepath.Join(dir, "b.json"))
+	})
+
+	t.Run("append ignores wildcard ids succeeds", func(t *testing.T) {
+		reg, err := jwalk.NewRegistry(jwalk.WithDirective(idDirective))
+		require.NoError(t, err)
+		got, err := newDocumentLoader(reg, false, MergeAppend).load(writeDirFiles(t, map[string]string{
+			"a.json": `{"users": [{"_id": {"$id": true}}]}`,
+			"b.json": `{"users": [{"_id": {"$id": true}}]}`,
+		}))
+		require.NoError(t, err)
+		id := jwalk.Document{{Key: "_id", Value: exp.Any("generated")}}
+		assert.Equal(t, jwalk.Document{{Key: "users", Value: jwalk.Array{id, id}}}, got)
+	})
+
+	t.Run("error strategy with shared collection returns error naming files", func(t *testing.T) {
+		dir := writeDirFiles(t, files)
+		_, err := newDocumentLoader(&jwalk.Registry{}, false, MergeError).load(dir)
+		require.Error(t, err)
+		assert.Equal(t, `collection "users" is defined by `+filepath.Join(dir, "a.json")+" and "+filepath.Join(dir, "b.json"), err.Error())
+	})
+
+	t.Run("error strategy with distinct collections succeeds", func(t *testing.T) {
+		got, err := newDocumentLoader(&jwalk.Registry{}, false, MergeError).load(writeDirFiles(t, map[string]string{
+			"a.json": `{"users": []}`,
+			"b.json": `{"tenants": []}`,
+		}))
+		require.NoError(t, err)
+		assert.Equal(t, jwalk.Document{{Key: "users", Value: jwalk.Array{}}, {Key: "tenants", Value: jwalk.Array{}}}, got)
+	})
+
+	t.Run("merging non array values returns error", func(t *testing.T) {
+		_, err := newDocumentLoader(&jwalk.Registry{}, false, MergeByID).load(writeDirFiles(t, map[string]string{
+			"a.json": `{"users": []}`,
+			"b.json": `{"users": {}}`,
+		}))
+		assert.Error(t, err)
+	})
+
+	t.Run("strategy applies to include list succeeds", func(t *testing.T) {
+		dir := writeDirFiles(t, map[string]string{
+			"a.json":    `{"users": [{"_id": 1}]}`,
+			"b.json":    `{"users": [{"_id": 2}]}`,
+			"test.json": `{"$include": ["a.json", "b.json"]}`,
+		})
+		got, err := newDocumentLoader(&jwalk.Registry{}, false, MergeAppend).load(filepath.Join(dir, "test.json"))
+		require.NoError(t, err)
+		assert.Equal(t, jwalk.Document{{Key: "users", Value: jwalk.Array{
+			jwalk.Document{{Key: "_id", Value: 1.0}},
+			jwalk.Document{{Key: "_id", Value: 2.0}},
+		}}}, got)
+	})
+}
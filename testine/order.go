@@ -0,0 +1,221 @@
+package testine
+
+import (
+	"cmp"
+	"fmt"
+	"reflect"
+	"slices"
+	"time"
+
+	"github.com/calumari/jwalk"
+
+	"github.com/calumari/poutine/exp"
+)
+
+// collectionOrder controls how the documents of a collection are lined up
+// before an expected and actual snapshot are compared.
+type collectionOrder struct {
+	unordered bool   // match documents as a multiset
+	sortKey   string // sort both sides by this field
+}
+
+// WithUnordered compares the given collections as multisets: each expected
+// document is matched against any actual document regardless of position.
+// Without arguments it applies to every collection.
+func WithUnordered(collections ...string) Option {
+	return withOrder(collectionOrder{unordered: true}, collections)
+}
+
+// WithSortBy sorts the expected and actual documents of the given
+// collections by key before comparing them. Without collections it applies
+// to every collection. Pattern values are sorted by their explicit value;
+// wildcards sort first, like missing keys.
+func WithSortBy(key string, collections ...string) Option {
+	return withOrder(collectionOrder{sortKey: key}, collections)
+}
+
+func withOrder(order collectionOrder, collections []string) Option {
+	return func(o *Options) {
+		if len(collections) == 0 {
+			o.defaultOrder = order
+			return
+		}
+		if o.order == nil {
+			o.order = make(map[string]collectionOrder)
+		}
+		for _, c := range collections {
+			o.order[c] = order
+		}
+	}
+}
+
+// orderer lines up the collections of two snapshots according to the
+// configured ordering.
+type orderer struct {
+	tester       Tester
+	order        map[string]collectionOrder
+	defaultOrder collectionOrder
+}
+
+func (o *orderer) collectionOrder(name string) collectionOrder {
+	if order, ok := o.order[name]; ok {
+		return order
+	}
+	return o.defaultOrder
+}
+
+// align returns copies of expected and actual whose collections are ordered
+// for an element-wise comparison. Collections without an ordering are
+// returned unchanged.
+func (o *orderer) align(expected, actual jwalk.Document, bindings *exp.Bindings) (jwalk.Document, jwalk.Document) {
+	if len(o.order) == 0 && o.defaultOrder == (collectionOrder{}) {
+		return expected, actual
+	}
+	expected, actual = slices.Clone(expected), slices.Clone(actual)
+	for i, e := range expected {
+		order := o.collectionOrder(e.Key)
+		if order == (collectionOrder{}) {
+			continue
+		}
+		expArr, ok := e.Value.(jwalk.Array)
+		if !ok {
+			continue
+		}
+		j := slices.IndexFunc(actual, func(a jwalk.Entry) bool { return a.Key == e.Key })
+		if j < 0 {
+			continue
+		}
+		actArr, ok := actual[j].Value.(jwalk.Array)
+		if !ok {
+			continue
+		}
+		if order.sortKey != "" {
+			expected[i].Value = sortByKey(expArr, order.sortKey)
+			actual[j].Value = sortByKey(actArr, order.sortKey)
+			continue
+		}
+		actual[j].Value = o.match(expArr, actArr, bindings)
+	}
+	return expected, actual
+}
+
+// match reorders actual so that every expected document that has an equal
+// actual document finds it at the same index. It computes a maximum
+// bipartite matching so that broad patterns do not steal documents needed by
+// more specific ones. Unmatched actual documents fill the remaining slots in
+// their original order; when actual is shorter the trailing slots shift.
+func (o *orderer) match(expected, actual jwalk.Array, bindings *exp.Bindings) jwalk.Array {
+	fits := make([][]int, len(expected))
+	for i, e := range expected {
+		for j, a := range actual {
+			bindings.Begin() // captures of a trial match must not leak
+			if o.tester.Test(e, a) == nil {
+				fits[i] = append(fits[i], j)
+			}
+		}
+	}
+	owner := make([]int, len(actual)) // actual index -> expected index
+	for j := range owner {
+		owner[j] = -1
+	}
+	var augment func(i int, seen []bool) bool
+	augment = func(i int, seen []bool) bool {
+		for _, j := range fits[i] {
+			if seen[j] {
+				continue
+			}
+			seen[j] = true
+			if owner[j] < 0 || augment(owner[j], seen) {
+				owner[j] = i
+				return true
+			}
+		}
+		return false
+	}
+	for i := range expected {
+		augment(i, make([]bool, len(actual)))
+	}
+
+	matched := make([]int, len(expected)) // expected index -> actual index
+	for i := range matched {
+		matched[i] = -1
+	}
+	var rest []any
+	for j, i := range owner {
+		if i >= 0 {
+			matched[i] = j
+		} else {
+			rest = append(rest, actual[j])
+		}
+	}
+	out := make(jwalk.Array, 0, len(actual))
+	for _, j := range matched {
+		switch {
+		case j >= 0:
+			out = append(out, actual[j])
+		case len(rest) > 0:
+			out, rest = append(out, rest[0]), rest[1:]
+		}
+	}
+	return append(out, rest...)
+}
+
+// sortByKey returns a copy of arr stably sorted by the value of key in each
+// document. Documents without the key, or whose key is a pattern without an
+// explicit value, sort first.
+func sortByKey(arr jwalk.Array, key string) jwalk.Array {
+	out := slices.Clone(arr)
+	slices.SortStableFunc(out, func(a, b any) int {
+		return compareValues(sortValue(a, key), sortValue(b, key))
+	})
+	return out
+}
+
+// sortValue returns the explicit value of key in v, or nil.
+func sortValue(v any, key string) any {
+	val, _ := documentKey(v, key)
+	return val
+}
+
+// compareValues orders numbers, strings and times naturally. Values of other
+// or mixed types are compared by their formatted representation.
+func compareValues(a, b any) int {
+	switch {
+	case a == nil && b == nil:
+		return 0
+	case a == nil:
+		return -1
+	case b == nil:
+		return 1
+	}
+	if ta, ok := a.(time.Time); ok {
+		if tb, ok := b.(time.Time); ok {
+			return ta.Compare(tb)
+		}
+	}
+	if fa, ok := toFloat(a); ok {
+		if fb, ok := toFloat(b); ok {
+			return cmp.Compare(fa, fb)
+		}
+	}
+	if sa, ok := a.(string); ok {
+		if sb, ok := b.(string); ok {
+			return cmp.Compare(sa, sb)
+		}
+	}
+	return cmp.Compare(fmt.Sprint(a), fmt.Sprint(b))
+}
+
+func toFloat(v any) (float64, bool) {
+	rv := reflect.ValueOf(v)
+	switch rv.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return float64(rv.Int()), true
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return float64(rv.Uint()), true
+	case reflect.Float32, reflect.Float64:
+		return rv.Float(), true
+	default:
+		return 0, false
+	}
+}
@@ -0,0 +1,162 @@
+package testine
+
+import (
+	"testing"
+	"time"
+
+	"github.com/calumari/jwalk"
+	"github.com/calumari/testequals"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/calumari/poutine/exp"
+)
+
+func pet(name string, age int) jwalk.Document {
+	return jwalk.Document{{Key: "name", Value: name}, {Key: "age", Value: age}}
+}
+
+func Test_orderer_match(t *testing.T) {
+	o := &orderer{tester: testequals.New()}
+	b := exp.NewBindings()
+
+	t.Run("reorders actual to expected order", func(t *testing.T) {
+		got := o.match(
+			jwalk.Array{pet("Luna", 3), pet("Max", 5), pet("Bella", 1)},
+			jwalk.Array{pet("Bella", 1), pet("Luna", 3), pet("Max", 5)},
+			b,
+		)
+		assert.Equal(t, jwalk.Array{pet("Luna", 3), pet("Max", 5), pet("Bella", 1)}, got)
+	})
+
+	t.Run("broad pattern does not steal specific match", func(t *testing.T) {
+		wildcard := jwalk.Document{{Key: "name", Value: exp.Any("x")}, {Key: "age", Value: 3}}
+		got := o.match(
+			jwalk.Array{wildcard, pet("Luna", 3)},
+			jwalk.Array{pet("Luna", 3), pet("Max", 3)},
+			b,
+		)
+		assert.Equal(t, jwalk.Array{pet("Max", 3), pet("Luna", 3)}, got)
+	})
+
+	t.Run("unmatched documents fill remaining slots", func(t *testing.T) {
+		got := o.match(
+			jwalk.Array{pet("Luna", 3), pet("Max", 5)},
+			jwalk.Array{pet("Max", 6), pet("Luna", 3), pet("Bella", 1)},
+			b,
+		)
+		assert.Equal(t, jwalk.Array{pet("Luna", 3), pet("Max", 6), pet("Bella", 1)}, got)
+	})
+
+	t.Run("shorter actual keeps matched documents", func(t *testing.T) {
+		got := o.match(
+			jwalk.Array{pet("Luna", 3), pet("Max", 5)},
+			jwalk.Array{pet("Max", 5)},
+			b,
+		)
+		assert.Equal(t, jwalk.Array{pet("Max", 5)}, got)
+	})
+}
+
+func Test_sortByKey(t *testing.T) {
+	t.Run("sorts numbers strings and missing keys", func(t *testing.T) {
+		got := sortByKey(jwalk.Array{
+			docKV("id", int32(10)),
+			docKV("id", 2.5),
+			docKV("other", true),
+			docKV("id", int64(3)),
+		}, "id")
+		assert.Equal(t, jwalk.Array{
+			docKV("other", true),
+			docKV("id", 2.5),
+			docKV("id", int64(3)),
+			docKV("id", int32(10)),
+		}, got)
+	})
+
+	t.Run("sorts patterns by explicit value", func(t *testing.T) {
+		got := sortByKey(jwalk.Array{docKV("id", exp.Value("b")), docKV("id", "a")}, "id")
+		assert.Equal(t, jwalk.Array{docKV("id", "a"), docKV("id", exp.Value("b"))}, got)
+	})
+
+	t.Run("sorts wildcard patterns as missing keys", func(t *testing.T) {
+		got := sortByKey(jwalk.Array{docKV("id", "a"), docKV("id", exp.Any("z"))}, "id")
+		assert.Equal(t, jwalk.Array{docKV("id", exp.Any("z")), docKV("id", "a")}, got)
+	})
+
+	t.Run("does not modify input", func(t *testing.T) {
+		in := jwalk.Array{docKV("id", 2), docKV("id", 1)}
+		sortByKey(in, "id")
+		assert.Equal(t, jwalk.Array{docKV("id", 2), docKV("id", 1)}, in)
+	})
+}
+
+func Test_compareValues(t *testing.T) {
+	now := time.Now()
+	tests := []struct {
+		name string
+		a, b any
+		want int
+	}{
+		{"numbers across types", int32(2), 10.0, -1},
+		{"strings", "b", "a", 1},
+		{"times", now, now.Add(time.Second), -1},
+		{"nil first", nil, "a", -1},
+		{"mixed types by format", "10", 9, -1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, compareValues(tt.a, tt.b))
+		})
+	}
+}
+
+func TestT_Assert_ordering(t *testing.T) {
+	seed := jwalk.Document{
+		{Key: "owners", Value: jwalk.Array{docKV("name", "Ann"), docKV("name", "Bob")}},
+		{Key: "pets", Value: jwalk.Array{pet("Luna", 3), pet("Max", 5)}},
+	}
+	reversed := jwalk.Document{
+		{Key: "owners", Value: jwalk.Array{docKV("name", "Bob"), docKV("name", "Ann")}},
+		{Key: "pets", Value: jwalk.Array{pet("Max", 5), pet("Luna", 3)}},
+	}
+
+	t.Run("ordered default mismatch returns fatal", func(t *testing.T) {
+		pt, _ := newMemoryT(t)
+		pt.Seed(t, seed)
+		ft := &mockTestingT{}
+		pt.Assert(ft, reversed)
+		assert.Contains(t, ft.fatal, "assert:")
+	})
+
+	t.Run("unordered all collections succeeds", func(t *testing.T) {
+		pt, _ := newMemoryT(t, WithUnordered())
+		pt.Seed(t, seed)
+		pt.Assert(t, reversed)
+	})
+
+	t.Run("unordered single collection keeps others ordered", func(t *testing.T) {
+		pt, _ := newMemoryT(t, WithUnordered("pets"))
+		pt.Seed(t, seed)
+		ft := &mockTestingT{}
+		pt.Assert(ft, reversed)
+		assert.Contains(t, ft.fatal, "owners[0].name")
+		assert.NotContains(t, ft.fatal, "pets[")
+	})
+
+	t.Run("sort by key succeeds", func(t *testing.T) {
+		pt, _ := newMemoryT(t, WithSortBy("name"))
+		pt.Seed(t, seed)
+		pt.Assert(t, reversed)
+	})
+
+	t.Run("unordered mismatch reports unmatched document", func(t *testing.T) {
+		pt, _ := newMemoryT(t, WithUnordered())
+		pt.Seed(t, seed)
+		ft := &mockTestingT{}
+		pt.Assert(ft, jwalk.Document{
+			{Key: "owners", Value: jwalk.Array{docKV("name", "Bob"), docKV("name", "Ann")}},
+			{Key: "pets", Value: jwalk.Array{pet("Max", 6), pet("Luna", 3)}},
+		})
+		assert.Contains(t, ft.fatal, "- pets[0].age: 6\n+ pets[0].age: 5\n")
+	})
+}
@@ -0,0 +1,116 @@
+package testine
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"strings"
+	"sync"
+
+	"github.com/calumari/poutine"
+	"github.com/calumari/poutine/database"
+)
+
+// maxTestNameLen bounds the part of a database name taken from the test name,
+// keeping names within the limits of common databases (63 bytes for MongoDB
+// and PostgreSQL).
+const maxTestNameLen = 40
+
+// Pool hands every test its own freshly created database. It is safe for
+// concurrent use, including from parallel tests.
+type Pool[D database.Driver] struct {
+	factory database.Factory[D]
+	opts    []Option
+
+	mu    sync.Mutex
+	tests map[TestingT]pooled[D]
+}
+
+type pooled[D database.Driver] struct {
+	pt     *T
+	driver D
+}
+
+// NewPool returns a pool creating databases with factory. Each helper is
+// created with opts, which must not include WithRegistry: every helper
+// registers its directives on a registry of its own.
+func NewPool[D database.Driver](factory database.Factory[D], opts ...Option) *Pool[D] {
+	return &Pool[D]{
+		factory: factory,
+		opts:    opts,
+		tests:   make(map[TestingT]pooled[D]),
+	}
+}
+
+// For returns the helper of t, creating a database named after the test on
+// first use. The database is torn down when t completes.
+func (p *Pool[D]) For(t TestingT) *T {
+	t.Helper()
+	return p.get(t).pt
+}
+
+// Driver returns the driver behind For(t), e.g. to build the code under test
+// against the same database.
+func (p *Pool[D]) Driver(t TestingT) D {
+	t.Helper()
+	return p.get(t).driver
+}
+
+func (p *Pool[D]) get(t TestingT) pooled[D] {
+	t.Helper()
+	p.mu.Lock()
+	entry, ok := p.tests[t]
+	p.mu.Unlock()
+	if ok {
+		return entry
+	}
+
+	name := databaseName(t)
+	driver, err := p.factory.Create(t.Context(), name)
+	if err != nil {
+		t.Fatalf("create database %s: %v", name, err)
+		return pooled[D]{}
+	}
+	pt, err := New(poutine.New(driver), p.opts...)
+	if err != nil {
+		t.Fatalf("create test helper: %v", err)
+		return pooled[D]{}
+	}
+	pt.Cleanup(t)
+	entry = pooled[D]{pt: pt, driver: driver}
+
+	p.mu.Lock()
+	p.tests[t] = entry
+	p.mu.Unlock()
+	t.Cleanup(func() {
+		p.mu.Lock()
+		delete(p.tests, t)
+		p.mu.Unlock()
+	})
+	return entry
+}
+
+// databaseName returns a unique database name, derived from the test name
+// when t has one: lower-case letters, digits and underscores only.
+func databaseName(t TestingT) string {
+	var sb strings.Builder
+	sb.WriteString("poutine_")
+	if named, ok := t.(interface{ Name() string }); ok {
+		n := 0
+		for _, r := range strings.ToLower(named.Name()) {
+			if n == maxTestNameLen {
+				break
+			}
+			if r >= 'a' && r <= 'z' || r >= '0' && r <= '9' {
+				sb.WriteRune(r)
+			} else {
+				sb.WriteByte('_')
+			}
+			n++
+		}
+		sb.WriteByte('_')
+	}
+	var suffix [6]byte
+	_, _ = rand.Read(suffix[:])
+	sb.WriteString(hex.EncodeToString(suffix[:]))
+	return sb.String()
+}
@@ -0,0 +1,98 @@
+package testine
+
+import (
+	"context"
+	"regexp"
+	"sync"
+	"testing"
+
+	"github.com/calumari/jwalk"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/calumari/poutine/database"
+	"github.com/calumari/poutine/database/memory"
+)
+
+// memoryFactory records the databases it creates.
+type memoryFactory struct {
+	mu      sync.Mutex
+	created map[string]*memory.Driver
+}
+
+func (f *memoryFactory) Create(_ context.Context, name string) (*memory.Driver, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.created == nil {
+		f.created = make(map[string]*memory.Driver)
+	}
+	d := memory.NewDriver()
+	f.created[name] = d
+	return d, nil
+}
+
+func TestPool(t *testing.T) {
+	t.Run("parallel tests get isolated databases succeeds", func(t *testing.T) {
+		factory := &memoryFactory{}
+		pool := NewPool[*memory.Driver](factory)
+		seed := jwalk.Document{{Key: "pets", Value: jwalk.Array{jwalk.Document{{Key: "name", Value: "Luna"}}}}}
+		t.Run("group", func(t *testing.T) {
+			for _, name := range []string{"a", "b", "c"} {
+				t.Run(name, func(t *testing.T) {
+					t.Parallel()
+					pt := pool.For(t)
+					assert.Same(t, pt, pool.For(t))
+					pt.Seed(t, seed)
+					pt.Assert(t, seed) // no documents from the other tests
+				})
+			}
+		})
+		require.Len(t, factory.created, 3)
+		for name, d := range factory.created {
+			assert.Regexp(t, `^poutine_testpool_parallel_tests_get_isolated_dat_[0-9a-f]{12}$`, name)
+			got, err := d.Snapshot(t.Context())
+			require.NoError(t, err)
+			assert.Empty(t, got, "database %s was not torn down", name)
+		}
+		assert.Empty(t, pool.tests)
+	})
+
+	t.Run("driver returns database of test succeeds", func(t *testing.T) {
+		factory := &memoryFactory{}
+		pool := NewPool[*memory.Driver](factory)
+		d := pool.Driver(t)
+		for _, created := range factory.created {
+			assert.Same(t, created, d)
+		}
+		assert.Len(t, factory.created, 1)
+	})
+
+	t.Run("factory error returns fatal", func(t *testing.T) {
+		pool := NewPool(database.FactoryFunc[*memory.Driver](func(context.Context, string) (*memory.Driver, error) {
+			return nil, assert.AnError
+		}))
+		ft := &mockTestingT{}
+		assert.Nil(t, pool.For(ft))
+		assert.Regexp(t, "^create database poutine_[0-9a-f]{12}: ", ft.fatal)
+	})
+
+	t.Run("options apply to every helper succeeds", func(t *testing.T) {
+		pool := NewPool[*memory.Driver](&memoryFactory{}, WithFakeSeed(42))
+		ft := &mockTestingT{}
+		assert.Equal(t, uint64(42), pool.For(ft).FakeSeed())
+	})
+}
+
+func Test_databaseName(t *testing.T) {
+	t.Run("unnamed test gets random name succeeds", func(t *testing.T) {
+		a, b := databaseName(&mockTestingT{}), databaseName(&mockTestingT{})
+		assert.NotEqual(t, a, b)
+		assert.Regexp(t, regexp.MustCompile(`^poutine_[0-9a-f]{12}$`), a)
+	})
+
+	t.Run("long test name is truncated succeeds", func(t *testing.T) {
+		name := databaseName(t)
+		assert.LessOrEqual(t, len(name), 63)
+		assert.Regexp(t, `^poutine_test_databasename_long_test_name_is_trun_[0-9a-f]{12}$`, name)
+	})
+}
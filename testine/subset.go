@@ -0,0 +1,93 @@
+package testine
+
+import (
+	"slices"
+
+	"github.com/calumari/jwalk"
+)
+
+// WithSubsetCollections leaves collections of the snapshot that are not
+// listed in the expected document, e.g. audit logs written by unrelated code,
+// out of failure diffs and of fixtures rewritten in update mode. The default
+// tester already ignores them when comparing.
+func WithSubsetCollections() Option {
+	return func(o *Options) { o.subset.collections = true }
+}
+
+// WithSubsetFields leaves fields of snapshot documents that are not present
+// in the corresponding expected document, at any depth, out of failure diffs
+// and of fixtures rewritten in update mode. The default tester already
+// ignores them when comparing.
+func WithSubsetFields() Option {
+	return func(o *Options) { o.subset.fields = true }
+}
+
+// subset narrows an aligned actual snapshot to the parts described by the
+// expected document, so that diffs and fixture updates only show what a test
+// asserts.
+type subset struct {
+	collections bool
+	fields      bool
+}
+
+// prune returns actual without the collections and fields left out by s.
+func (s subset) prune(expected, actual jwalk.Document) jwalk.Document {
+	return s.pruneDocuments(expected, s.pruneCollections(expected, actual))
+}
+
+// pruneCollections drops collections of actual that are not in expected.
+func (s subset) pruneCollections(expected, actual jwalk.Document) jwalk.Document {
+	if !s.collections {
+		return actual
+	}
+	return slices.DeleteFunc(slices.Clone(actual), func(e jwalk.Entry) bool {
+		_, ok := lookup(expected, e.Key)
+		return !ok
+	})
+}
+
+// pruneDocuments applies pruneFields to every collection of actual that is
+// also in expected.
+func (s subset) pruneDocuments(expected, actual jwalk.Document) jwalk.Document {
+	if !s.fields {
+		return actual
+	}
+	out := slices.Clone(actual)
+	for i, e := range out {
+		if ev, ok := lookup(expected, e.Key); ok {
+			out[i].Value = s.pruneFields(ev, e.Value)
+		}
+	}
+	return out
+}
+
+// pruneFields drops fields of actual documents that are not in the
+// corresponding expected documents. Arrays are narrowed element by element.
+func (s subset) pruneFields(expected, actual any) any {
+	switch exp := expected.(type) {
+	case jwalk.Document:
+		act, ok := actual.(jwalk.Document)
+		if !ok {
+			return actual
+		}
+		out := make(jwalk.Document, 0, len(exp))
+		for _, e := range act {
+			if ev, ok := lookup(exp, e.Key); ok {
+				out = append(out, jwalk.Entry{Key: e.Key, Value: s.pruneFields(ev, e.Value)})
+			}
+		}
+		return out
+	case jwalk.Array:
+		act, ok := actual.(jwalk.Array)
+		if !ok {
+			return actual
+		}
+		out := slices.Clone(act)
+		for i := range min(len(exp), len(act)) {
+			out[i] = s.pruneFields(exp[i], act[i])
+		}
+		return out
+	default:
+		return actual
+	}
+}
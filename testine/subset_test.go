@@ -0,0 +1,119 @@
+package testine
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/calumari/jwalk"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_subset_pruneCollections(t *testing.T) {
+	actual := jwalk.Document{
+		{Key: "audit", Value: jwalk.Array{docKV("event", "login")}},
+		{Key: "pets", Value: jwalk.Array{pet("Luna", 3)}},
+	}
+
+	t.Run("enabled drops unlisted collections", func(t *testing.T) {
+		got := subset{collections: true}.pruneCollections(docKV("pets", jwalk.Array{}), actual)
+		assert.Equal(t, docKV("pets", jwalk.Array{pet("Luna", 3)}), got)
+		assert.Len(t, actual, 2)
+	})
+
+	t.Run("disabled returns actual", func(t *testing.T) {
+		got := subset{}.pruneCollections(docKV("pets", jwalk.Array{}), actual)
+		assert.Equal(t, actual, got)
+	})
+}
+
+func Test_subset_pruneFields(t *testing.T) {
+	s := subset{fields: true}
+
+	t.Run("drops fields missing from expected at any depth", func(t *testing.T) {
+		expected := jwalk.Document{
+			{Key: "name", Value: "Luna"},
+			{Key: "owner", Value: docKV("name", "Ann")},
+		}
+		actual := jwalk.Document{
+			{Key: "_id", Value: "p1"},
+			{Key: "name", Value: "Luna"},
+			{Key: "owner", Value: jwalk.Document{{Key: "name", Value: "Ann"}, {Key: "email", Value: "ann@example.com"}}},
+		}
+		got := s.pruneFields(expected, actual)
+		assert.Equal(t, expected, got)
+	})
+
+	t.Run("arrays prune element wise and keep extra elements", func(t *testing.T) {
+		got := s.pruneFields(
+			jwalk.Array{docKV("name", "Luna")},
+			jwalk.Array{pet("Luna", 3), pet("Max", 5)},
+		)
+		assert.Equal(t, jwalk.Array{docKV("name", "Luna"), pet("Max", 5)}, got)
+	})
+
+	t.Run("type mismatch returns actual", func(t *testing.T) {
+		assert.Equal(t, "x", s.pruneFields(docKV("a", 1), "x"))
+	})
+
+	t.Run("pruneDocuments keeps unlisted collections", func(t *testing.T) {
+		got := s.pruneDocuments(
+			docKV("pets", jwalk.Array{docKV("name", "Luna")}),
+			jwalk.Document{
+				{Key: "audit", Value: jwalk.Array{docKV("event", "login")}},
+				{Key: "pets", Value: jwalk.Array{pet("Luna", 3)}},
+			},
+		)
+		assert.Equal(t, jwalk.Document{
+			{Key: "audit", Value: jwalk.Array{docKV("event", "login")}},
+			{Key: "pets", Value: jwalk.Array{docKV("name", "Luna")}},
+		}, got)
+	})
+}
+
+func TestT_Assert_subset(t *testing.T) {
+	seed := jwalk.Document{
+		{Key: "audit", Value: jwalk.Array{docKV("event", "created")}},
+		{Key: "pets", Value: jwalk.Array{pet("Luna", 3)}},
+	}
+	expected := docKV("pets", jwalk.Array{docKV("name", "Luna")})
+
+	t.Run("subset options pass strict tester", func(t *testing.T) {
+		mp := &mockPoutine{}
+		mp.On("RegisterTypes", mock.Anything).Return(nil).Maybe()
+		mp.On("Snapshot", mock.Anything).Return(seed, nil).Once()
+		mt := &mockTester{}
+		mt.On("Test", expected, expected).Return(nil).Once()
+		pt, err := New(mp, WithTester(mt), WithSubsetCollections(), WithSubsetFields())
+		require.NoError(t, err)
+		pt.Assert(&mockTestingT{}, expected)
+		mt.AssertExpectations(t)
+	})
+
+	t.Run("update mode keeps fixture to listed collections and fields", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "after.json")
+		require.NoError(t, os.WriteFile(path, []byte(`{"pets": [{"name": "Luna"}]}`), 0o644))
+		pt, driver := newMemoryT(t, WithUpdate(), WithSubsetCollections(), WithSubsetFields())
+		pt.Seed(t, seed)
+		driver.Insert("pets", pet("Max", 5))
+
+		pt.Assert(t, pt.LoadJSON(t, path))
+		got, err := os.ReadFile(path)
+		require.NoError(t, err)
+		want := `{
+  "pets": [
+    {
+      "name": "Luna"
+    },
+    {
+      "name": "Max",
+      "age": 5
+    }
+  ]
+}
+`
+		assert.Equal(t, want, string(got))
+	})
+}
@@ -0,0 +1,415 @@
+package testine
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"maps"
+	"math"
+	"reflect"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+
+	"github.com/go-json-experiment/json"
+	"github.com/go-json-experiment/json/jsontext"
+)
+
+// template expands a fixture before it is decoded, so the expanded JSON still
+// flows through the registered directives. It supports:
+//
+//	"${name}"                                   // a variable, keeping its JSON type
+//	"user-${i + 1}@example.com"                 // interpolation into a string
+//	{"$repeat": 3, "as": "i", "each": {...}}    // as an array element: 3 copies of each
+//
+// Expressions are variables (with dotted access into maps), numbers and the
+// operators + - * / % with parentheses; % takes integers. The index of the
+// innermost $repeat is named by "as" and defaults to "i". "now" is the time
+// the fixture was loaded, in RFC 3339. "$${" escapes a literal "${".
+type template struct {
+	vars       map[string]any
+	marshalers *json.Marshalers
+}
+
+// newTemplate returns a template over vars. Variables used as a whole value
+// are encoded with marshalers, so driver values such as ObjectIDs turn into
+// the directives that decode them again.
+func newTemplate(vars map[string]any, marshalers *json.Marshalers) *template {
+	scope := map[string]any{"now": time.Now().UTC().Format(time.RFC3339Nano)}
+	maps.Copy(scope, vars)
+	return &template{vars: scope, marshalers: marshalers}
+}
+
+// expand returns the expanded JSON of a template file.
+func (tp *template) expand(data []byte) ([]byte, error) {
+	raw, err := parseRaw(jsontext.Value(data))
+	if err != nil {
+		return nil, err
+	}
+	var buf bytes.Buffer
+	enc := jsontext.NewEncoder(&buf)
+	if err := tp.render(enc, raw, tp.vars); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+func (tp *template) render(enc *jsontext.Encoder, n *rawNode, scope map[string]any) error {
+	switch n.value.Kind() {
+	case '{':
+		if isRepeat(n) {
+			return errors.New("$repeat must be an array element")
+		}
+		if err := enc.WriteToken(jsontext.BeginObject); err != nil {
+			return err
+		}
+		for i, key := range n.keys {
+			k, err := interpolate(key, scope)
+			if err != nil {
+				return fmt.Errorf("key %q: %w", key, err)
+			}
+			if err := enc.WriteToken(jsontext.String(k)); err != nil {
+				return err
+			}
+			if err := tp.render(enc, n.members[i], scope); err != nil {
+				return fmt.Errorf("%s: %w", key, err)
+			}
+		}
+		return enc.WriteToken(jsontext.EndObject)
+	case '[':
+		if err := enc.WriteToken(jsontext.BeginArray); err != nil {
+			return err
+		}
+		for i, elem := range n.elems {
+			if err := tp.renderElem(enc, elem, scope); err != nil {
+				return fmt.Errorf("[%d]: %w", i, err)
+			}
+		}
+		return enc.WriteToken(jsontext.EndArray)
+	case '"':
+		var s string
+		if err := json.Unmarshal(n.value, &s); err != nil {
+			return err
+		}
+		if expr, ok := wholeExpr(s); ok {
+			v, err := evalExpr(expr, scope)
+			if err != nil {
+				return err
+			}
+			return encodeValue(enc, v, tp.marshalers)
+		}
+		s, err := interpolate(s, scope)
+		if err != nil {
+			return err
+		}
+		return enc.WriteToken(jsontext.String(s))
+	default:
+		return enc.WriteValue(n.value)
+	}
+}
+
+// renderElem renders an array element, expanding $repeat blocks in place.
+func (tp *template) renderElem(enc *jsontext.Encoder, n *rawNode, scope map[string]any) error {
+	if !isRepeat(n) {
+		return tp.render(enc, n, scope)
+	}
+	var (
+		count int
+		as    = "i"
+		each  *rawNode
+	)
+	for i, key := range n.keys {
+		member := n.members[i]
+		switch key {
+		case "$repeat":
+			c, err := tp.count(member, scope)
+			if err != nil {
+				return fmt.Errorf("$repeat: %w", err)
+			}
+			count = c
+		case "as":
+			if err := json.Unmarshal(member.value, &as); err != nil || !isIdent(as) {
+				return fmt.Errorf("$repeat: invalid as %s", member.value)
+			}
+		case "each":
+			each = member
+		default:
+			return fmt.Errorf("$repeat: unknown field %q", key)
+		}
+	}
+	if each == nil {
+		return errors.New(`$repeat: missing "each"`)
+	}
+	for i := range count {
+		inner := maps.Clone(scope)
+		inner[as] = i
+		if err := tp.render(enc, each, inner); err != nil {
+			return fmt.Errorf("$repeat %s=%d: %w", as, i, err)
+		}
+	}
+	return nil
+}
+
+// count decodes a $repeat count given as a number or an expression.
+func (tp *template) count(n *rawNode, scope map[string]any) (int, error) {
+	var v any
+	if err := json.Unmarshal(n.value, &v); err != nil {
+		return 0, err
+	}
+	if s, ok := v.(string); ok {
+		expr, ok := wholeExpr(s)
+		if !ok {
+			return 0, fmt.Errorf("count %q is not an expression", s)
+		}
+		var err error
+		if v, err = evalExpr(expr, scope); err != nil {
+			return 0, err
+		}
+	}
+	f, ok := toFloat(v)
+	if !ok || f < 0 || f != float64(int(f)) {
+		return 0, fmt.Errorf("count must be a non-negative integer, got %v", v)
+	}
+	return int(f), nil
+}
+
+// isRepeat reports whether n is an object with a $repeat member.
+func isRepeat(n *rawNode) bool {
+	return n.value.Kind() == '{' && slices.Contains(n.keys, "$repeat")
+}
+
+// wholeExpr reports whether s consists of a single ${...} expression.
+func wholeExpr(s string) (string, bool) {
+	if !strings.HasPrefix(s, "${") || !strings.HasSuffix(s, "}") {
+		return "", false
+	}
+	end := strings.IndexByte(s, '}')
+	if end != len(s)-1 {
+		return "", false
+	}
+	return s[2:end], true
+}
+
+// interpolate replaces every ${...} expression in s with its value.
+func interpolate(s string, scope map[string]any) (string, error) {
+	if !strings.Contains(s, "${") {
+		return s, nil
+	}
+	var sb strings.Builder
+	for {
+		i := strings.Index(s, "${")
+		if i < 0 {
+			sb.WriteString(s)
+			return sb.String(), nil
+		}
+		if i > 0 && s[i-1] == '$' { // escaped
+			sb.WriteString(s[:i-1])
+			sb.WriteString("${")
+			s = s[i+2:]
+			continue
+		}
+		end := strings.IndexByte(s[i:], '}')
+		if end < 0 {
+			return "", fmt.Errorf("unterminated expression in %q", s)
+		}
+		v, err := evalExpr(s[i+2:i+end], scope)
+		if err != nil {
+			return "", err
+		}
+		sb.WriteString(s[:i])
+		sb.WriteString(formatScalar(v))
+		s = s[i+end+1:]
+	}
+}
+
+func formatScalar(v any) string {
+	switch val := v.(type) {
+	case float64:
+		return strconv.FormatFloat(val, 'f', -1, 64)
+	case string:
+		return val
+	default:
+		return fmt.Sprint(v)
+	}
+}
+
+// evalExpr evaluates a template expression. A lone variable keeps its value;
+// arithmetic yields a float64.
+func evalExpr(expr string, scope map[string]any) (any, error) {
+	p := &exprParser{src: expr, scope: scope}
+	if name := strings.TrimSpace(expr); isIdent(name) {
+		return p.lookup(name)
+	}
+	v, err := p.sum()
+	if err != nil {
+		return nil, fmt.Errorf("expression %q: %w", expr, err)
+	}
+	if p.skipSpace(); p.pos < len(p.src) {
+		return nil, fmt.Errorf("expression %q: unexpected %q", expr, p.src[p.pos:])
+	}
+	return v, nil
+}
+
+type exprParser struct {
+	src   string
+	pos   int
+	scope map[string]any
+}
+
+func (p *exprParser) sum() (float64, error) {
+	v, err := p.product()
+	if err != nil {
+		return 0, err
+	}
+	for {
+		p.skipSpace()
+		if p.pos >= len(p.src) || (p.src[p.pos] != '+' && p.src[p.pos] != '-') {
+			return v, nil
+		}
+		op := p.src[p.pos]
+		p.pos++
+		r, err := p.product()
+		if err != nil {
+			return 0, err
+		}
+		if op == '+' {
+			v += r
+		} else {
+			v -= r
+		}
+	}
+}
+
+func (p *exprParser) product() (float64, error) {
+	v, err := p.operand()
+	if err != nil {
+		return 0, err
+	}
+	for {
+		p.skipSpace()
+		if p.pos >= len(p.src) || !strings.ContainsRune("*/%", rune(p.src[p.pos])) {
+			return v, nil
+		}
+		op := p.src[p.pos]
+		p.pos++
+		r, err := p.operand()
+		if err != nil {
+			return 0, err
+		}
+		switch op {
+		case '*':
+			v *= r
+		case '/':
+			if r == 0 {
+				return 0, errors.New("division by zero")
+			}
+			v /= r
+		case '%':
+			if v != math.Trunc(v) || r != math.Trunc(r) {
+				return 0, fmt.Errorf("%% needs integers, got %v %% %v", v, r)
+			}
+			if r == 0 {
+				return 0, errors.New("division by zero")
+			}
+			v = math.Mod(v, r)
+		}
+	}
+}
+
+func (p *exprParser) operand() (float64, error) {
+	p.skipSpace()
+	if p.pos >= len(p.src) {
+		return 0, errors.New("unexpected end")
+	}
+	switch c := p.src[p.pos]; {
+	case c == '(':
+		p.pos++
+		v, err := p.sum()
+		if err != nil {
+			return 0, err
+		}
+		if p.skipSpace(); p.pos >= len(p.src) || p.src[p.pos] != ')' {
+			return 0, errors.New("missing )")
+		}
+		p.pos++
+		return v, nil
+	case c == '-':
+		p.pos++
+		v, err := p.operand()
+		return -v, err
+	case c >= '0' && c <= '9' || c == '.':
+		start := p.pos
+		for p.pos < len(p.src) && (p.src[p.pos] >= '0' && p.src[p.pos] <= '9' || p.src[p.pos] == '.') {
+			p.pos++
+		}
+		return strconv.ParseFloat(p.src[start:p.pos], 64)
+	case isIdentRune(rune(c), true):
+		start := p.pos
+		for p.pos < len(p.src) && isIdentRune(rune(p.src[p.pos]), false) {
+			p.pos++
+		}
+		name := p.src[start:p.pos]
+		v, err := p.lookup(name)
+		if err != nil {
+			return 0, err
+		}
+		f, ok := toFloat(v)
+		if !ok {
+			return 0, fmt.Errorf("%s is not a number (%T)", name, v)
+		}
+		return f, nil
+	default:
+		return 0, fmt.Errorf("unexpected %q", c)
+	}
+}
+
+// lookup resolves a variable, following dots into nested maps.
+func (p *exprParser) lookup(name string) (any, error) {
+	parts := strings.Split(name, ".")
+	v, ok := p.scope[parts[0]]
+	for _, part := range parts[1:] {
+		if !ok {
+			break
+		}
+		rv := reflect.ValueOf(v)
+		if rv.Kind() != reflect.Map || rv.Type().Key().Kind() != reflect.String {
+			ok = false
+			break
+		}
+		field := rv.MapIndex(reflect.ValueOf(part).Convert(rv.Type().Key()))
+		if ok = field.IsValid(); ok {
+			v = field.Interface()
+		}
+	}
+	if !ok {
+		return nil, fmt.Errorf("undefined variable %q", name)
+	}
+	return v, nil
+}
+
+func (p *exprParser) skipSpace() {
+	for p.pos < len(p.src) && p.src[p.pos] == ' ' {
+		p.pos++
+	}
+}
+
+func isIdent(s string) bool {
+	if s == "" {
+		return false
+	}
+	for i, r := range s {
+		if !isIdentRune(r, i == 0) {
+			return false
+		}
+	}
+	return true
+}
+
+func isIdentRune(r rune, first bool) bool {
+	if r == '_' || unicode.IsLetter(r) {
+		return true
+	}
+	return !first && (r == '.' || unicode.IsDigit(r))
+}
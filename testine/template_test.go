@@ -0,0 +1,152 @@
+package testine
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/calumari/jwalk"
+	"github.com/go-json-experiment/json"
+	"github.com/go-json-experiment/json/jsontext"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/calumari/poutine/database/memory"
+	"github.com/calumari/poutine/exp"
+)
+
+func Test_template_expand(t *testing.T) {
+	tests := []struct {
+		name string
+		vars map[string]any
+		in   string
+		want string
+	}{
+		{
+			name: "whole expression keeps type",
+			vars: map[string]any{"n": 3, "tags": []string{"a"}},
+			in:   `{"n": "${n}", "tags": "${tags}"}`,
+			want: `{"n":3,"tags":["a"]}`,
+		},
+		{
+			name: "interpolates into strings and keys",
+			vars: map[string]any{"user": map[string]any{"name": "alice"}},
+			in:   `{"${user.name}": "hello ${user.name}!"}`,
+			want: `{"alice":"hello alice!"}`,
+		},
+		{
+			name: "arithmetic",
+			vars: map[string]any{"page": 2},
+			in:   `["${page * 10 + 1}", "${(page - 1) % 2}", "id-${-page / 4}"]`,
+			want: `[21,1,"id--0.5"]`,
+		},
+		{
+			name: "repeat generates indexed elements",
+			in:   `[{"$repeat": 3, "each": {"name": "user-${i}"}}]`,
+			want: `[{"name":"user-0"},{"name":"user-1"},{"name":"user-2"}]`,
+		},
+		{
+			name: "repeat with named index and variable count",
+			vars: map[string]any{"pages": 2},
+			in:   `[{"id": 0}, {"$repeat": "${pages}", "as": "p", "each": {"id": "${p + 1}"}}]`,
+			want: `[{"id":0},{"id":1},{"id":2}]`,
+		},
+		{
+			name: "repeat after each",
+			in:   `[{"each": "${i % 2}", "$repeat": 3}]`,
+			want: `[0, 1, 0]`,
+		},
+		{
+			name: "nested repeat",
+			in:   `[{"$repeat": 2, "as": "a", "each": [{"$repeat": 2, "each": "${a}${i}"}]}]`,
+			want: `[["00","01"],["10","11"]]`,
+		},
+		{
+			name: "escaped expression stays literal",
+			in:   `"cost: $${price}"`,
+			want: `"cost: ${price}"`,
+		},
+		{
+			name: "directives pass through",
+			vars: map[string]any{"id": "abc"},
+			in:   `{"_id": {"$id": "${id}"}}`,
+			want: `{"_id":{"$id":"abc"}}`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name+" succeeds", func(t *testing.T) {
+			got, err := newTemplate(tt.vars, nil).expand([]byte(tt.in))
+			require.NoError(t, err)
+			assert.JSONEq(t, tt.want, string(got))
+		})
+	}
+
+	t.Run("whole expression encodes driver values with marshalers succeeds", func(t *testing.T) {
+		type color struct{ name string }
+		marshalers := json.MarshalToFunc(func(enc *jsontext.Encoder, c color) error {
+			return enc.WriteValue(jsontext.Value(`{"$color":"` + c.name + `"}`))
+		})
+		got, err := newTemplate(map[string]any{"c": color{name: "red"}}, marshalers).expand([]byte(`{"paint": "${c}"}`))
+		require.NoError(t, err)
+		assert.JSONEq(t, `{"paint":{"$color":"red"}}`, string(got))
+	})
+
+	t.Run("now expands to current time succeeds", func(t *testing.T) {
+		got, err := newTemplate(nil, nil).expand([]byte(`"${now}"`))
+		require.NoError(t, err)
+		ts, err := time.Parse(`"`+time.RFC3339Nano+`"`, strings.TrimSpace(string(got)))
+		require.NoError(t, err)
+		assert.WithinDuration(t, time.Now(), ts, time.Minute)
+	})
+
+	errTests := []struct {
+		name string
+		in   string
+	}{
+		{"undefined variable", `"${missing}"`},
+		{"unterminated expression", `"a ${i"`},
+		{"non numeric arithmetic", `"${now + 1}"`},
+		{"repeat outside array", `{"$repeat": 1, "each": 1}`},
+		{"repeat without each", `[{"$repeat": 1}]`},
+		{"repeat with negative count", `[{"$repeat": -1, "each": 1}]`},
+		{"repeat with unknown field", `[{"$repeat": 1, "each": 1, "extra": 1}]`},
+		{"division by zero", `"${1 / 0}"`},
+		{"modulo by zero", `"${1 % 0}"`},
+		{"modulo by fraction", `"${1 % 0.5}"`},
+		{"repeat after each outside array", `{"each": 1, "$repeat": 1}`},
+	}
+	for _, tt := range errTests {
+		t.Run(tt.name+" returns error", func(t *testing.T) {
+			_, err := newTemplate(nil, nil).expand([]byte(tt.in))
+			assert.Error(t, err)
+		})
+	}
+}
+
+func TestT_LoadJSONWith(t *testing.T) {
+	t.Run("expanded fixture decodes directives succeeds", func(t *testing.T) {
+		driver := memory.NewDriver()
+		pt := newGoldenT(t, driver)
+		path := writeTemp(t, "tpl_*.json", `{"users": [{"$repeat": "${count}", "each": {"id": {"$id": "u${i}"}, "page": "${i / pageSize}"}}]}`)
+		doc := pt.LoadJSONWith(t, path, map[string]any{"count": 3, "pageSize": 2})
+		require.Len(t, doc, 1)
+		users := doc[0].Value.(jwalk.Array)
+		require.Len(t, users, 3)
+		assert.Equal(t, jwalk.Document{{Key: "id", Value: exp.Value("u2")}, {Key: "page", Value: 1.0}}, users[2])
+	})
+
+	t.Run("variables are not cached between loads succeeds", func(t *testing.T) {
+		pt, _ := newMemoryT(t, WithDocumentCache())
+		path := writeTemp(t, "tpl_*.json", `{"n": "${n}"}`)
+		assert.Equal(t, 1.0, pt.LoadJSONWith(t, path, map[string]any{"n": 1})[0].Value)
+		assert.Equal(t, 2.0, pt.LoadJSONWith(t, path, map[string]any{"n": 2})[0].Value)
+	})
+
+	t.Run("template error fails test", func(t *testing.T) {
+		pt, _ := newMemoryT(t)
+		path := writeTemp(t, "tpl_*.json", `{"n": "${n}"}`)
+		ft := &mockTestingT{}
+		pt.LoadJSONWith(ft, path, nil)
+		assert.Contains(t, ft.fatal, `undefined variable "n"`)
+	})
+}
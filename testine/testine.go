@@ -2,11 +2,22 @@ package testine
 
 import (
 	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"slices"
+	"strings"
+	"sync"
 
 	"github.com/calumari/jwalk"
 	"github.com/calumari/testequals"
+	"github.com/go-json-experiment/json"
 
 	"github.com/calumari/poutine"
+	"github.com/calumari/poutine/database"
+	"github.com/calumari/poutine/exp"
 )
 
 type Tester interface {
@@ -17,6 +28,13 @@ type Options struct {
 	Tester         Tester
 	Registry       *jwalk.Registry
 	cacheDocuments bool
+	color          bool
+	update         bool
+	order          map[string]collectionOrder
+	defaultOrder   collectionOrder
+	subset         subset
+	merge          MergeStrategy
+	fakeSeed       uint64
 }
 
 type Option func(*Options)
@@ -24,13 +42,37 @@ type Option func(*Options)
 func WithTester(t Tester) Option {
 	return func(o *Options) { o.Tester = t }
 }
+
+// WithRegistry decodes fixtures with r instead of a new registry. The
+// matchers and $fake are added unless an earlier helper added them, so r may
+// be shared by several helpers. Helpers sharing r share the $fake generator,
+// and New fails if one asks for a different seed.
 func WithRegistry(r *jwalk.Registry) Option {
 	return func(o *Options) { o.Registry = r }
 }
+
+// WithDocumentCache caches decoded fixtures by path. Cached documents keep the
+// values $fake drew when they were first decoded; use LoadJSONWith, which
+// never caches, for fresh fake data on every load.
 func WithDocumentCache() Option {
 	return func(o *Options) { o.cacheDocuments = true }
 }
 
+// WithUpdate enables golden-file update mode. A failing Assert against a
+// document loaded from a single .json file by LoadJSON rewrites that file with
+// the actual snapshot and logs it instead of failing; values it captured are
+// not bound. Update mode is also enabled by the
+// -poutine.update test flag.
+func WithUpdate() Option {
+	return func(o *Options) { o.update = true }
+}
+
+// WithColor enables or disables ANSI colors in assertion diffs. Colors are
+// enabled by default unless the NO_COLOR environment variable is set.
+func WithColor(enabled bool) Option {
+	return func(o *Options) { o.color = enabled }
+}
+
 type Poutine interface {
 	Seed(ctx context.Context, root jwalk.Document) (jwalk.Document, error)
 	Snapshot(ctx context.Context) (jwalk.Document, error)
@@ -42,17 +84,34 @@ type TestingT interface {
 	Cleanup(func())
 	Fatalf(format string, args ...any)
 	Helper()
+	Logf(format string, args ...any)
 }
 
 type T struct {
-	poutine  Poutine
-	tester   Tester
-	registry *jwalk.Registry
-	loader   *documentLoader
+	poutine    Poutine
+	tester     Tester
+	registry   *jwalk.Registry
+	loader     *documentLoader
+	differ     *differ
+	orderer    *orderer
+	subset     subset
+	marshalers *json.Marshalers
+	faker      *faker
+	update     bool
+
+	mu       sync.Mutex
+	sources  []*loadedSource              // documents loaded from a file in update mode
+	txCtxs   map[TestingT]context.Context // transaction context of each test that called Begin
+	bindings map[TestingT]*exp.Bindings   // named references of each test
 }
 
 func New(p Poutine, opts ...Option) (*T, error) {
-	op := &Options{Tester: testequals.New()}
+	op := &Options{
+		Tester:   testequals.New(testequals.WithCollectAll()),
+		color:    os.Getenv("NO_COLOR") == "",
+		update:   *updateFlag,
+		fakeSeed: *seedFlag,
+	}
 	for _, o := range opts {
 		o(op)
 	}
@@ -64,38 +123,139 @@ func New(p Poutine, opts ...Option) (*T, error) {
 		}
 		reg = r
 	}
+	if err := exp.RegisterMatchers(reg); err != nil {
+		return nil, err
+	}
+	faker, err := registerFaker(reg, op.fakeSeed)
+	if err != nil {
+		return nil, err
+	}
 	if regDriver, ok := p.(poutine.Registrar); ok {
 		if err := regDriver.RegisterTypes(reg); err != nil {
 			return nil, err
 		}
 	}
+	tester := absenceTester{op.Tester}
+	var marshalers *json.Marshalers
+	if enc, ok := p.(poutine.Encoder); ok {
+		marshalers = enc.Marshalers()
+	}
 	t := &T{
-		poutine:  p,
-		tester:   op.Tester,
-		registry: reg,
+		poutine:    p,
+		tester:     tester,
+		registry:   reg,
+		differ:     &differ{tester: tester, color: op.color},
+		orderer:    &orderer{tester: tester, order: op.order, defaultOrder: op.defaultOrder},
+		subset:     op.subset,
+		marshalers: marshalers,
+		faker:      faker,
+		update:     op.update,
+		txCtxs:     make(map[TestingT]context.Context),
+		bindings:   make(map[TestingT]*exp.Bindings),
 	}
-	t.loader = newDocumentLoader(reg, op.cacheDocuments)
+	t.loader = newDocumentLoader(reg, op.cacheDocuments, op.merge)
 	return t, nil
 }
 
 func (pt *T) Seed(t TestingT, root jwalk.Document) *Snapshot {
 	t.Helper()
-	actual, err := pt.poutine.Seed(t.Context(), root)
+	if path, ok := absentPath(root, ""); ok {
+		t.Fatalf("seed: %s: $absent cannot be seeded", path)
+		return nil
+	}
+	actual, err := pt.poutine.Seed(pt.context(t), pt.bind(t, root))
 	if err != nil {
-		t.Fatalf("seed: %v", err)
+		t.Fatalf("seed: %v%s", err, pt.faker.note())
 	}
 	return &Snapshot{pt: pt, expected: actual}
 }
 
-func (pt *T) Assert(t TestingT, expected jwalk.Document) {
+// Assert compares the current snapshot with expected. On success it returns
+// every named reference bound so far, including values captured by this
+// assertion, so later steps and fixtures can reuse them.
+func (pt *T) Assert(t TestingT, expected jwalk.Document) map[string]any {
 	t.Helper()
-	actual, err := pt.poutine.Snapshot(t.Context())
+	actual, err := pt.poutine.Snapshot(pt.context(t))
 	if err != nil {
 		t.Fatalf("snapshot: %v", err)
 	}
-	if err := pt.tester.Test(expected, actual); err != nil {
-		t.Fatalf("assert: %v", err)
+	source, fromFile := pt.source(expected)
+	bindings := pt.testBindings(t)
+	expected = pt.bind(t, expected)
+	aligned, actual := pt.orderer.align(expected, actual, bindings)
+	actual = pt.subset.prune(aligned, actual)
+	bindings.Begin()
+	if err := pt.tester.Test(aligned, actual); err != nil {
+		if fromFile {
+			// the fixture is written against its own order; directives are
+			// only preserved where that order lines up with the snapshot
+			bindings.Rollback()
+			if err := pt.updateGolden(source, expected, actual); err != nil {
+				t.Fatalf("update %s: %v", source, err)
+				return nil
+			}
+			t.Logf("updated %s with the actual snapshot", source)
+			return bindings.Map()
+		}
+		t.Fatalf("assert: %s", pt.failure(err, aligned, actual))
+		bindings.Rollback()
+		return nil
 	}
+	bindings.Commit()
+	return bindings.Map()
+}
+
+// FakeSeed returns the seed of the generator behind the $fake directive.
+func (pt *T) FakeSeed() uint64 {
+	return pt.faker.seed
+}
+
+// Bindings returns every named reference bound so far by t. Each test has its
+// own references, which are dropped when it completes.
+func (pt *T) Bindings(t TestingT) map[string]any {
+	return pt.testBindings(t).Map()
+}
+
+// testBindings returns the named references of t, creating them on first use.
+func (pt *T) testBindings(t TestingT) *exp.Bindings {
+	pt.mu.Lock()
+	b, ok := pt.bindings[t]
+	if !ok {
+		b = exp.NewBindings()
+		pt.bindings[t] = b
+	}
+	pt.mu.Unlock()
+	if ok {
+		return b
+	}
+	t.Cleanup(func() {
+		pt.mu.Lock()
+		delete(pt.bindings, t)
+		pt.mu.Unlock()
+	})
+	return b
+}
+
+// failure describes a failed assertion, listing every mismatch reported by
+// the tester followed by a diff of the expected and actual snapshots.
+func (pt *T) failure(err error, expected, actual jwalk.Document) string {
+	var sb strings.Builder
+	var multi *testequals.MultiError
+	if errors.As(err, &multi) && len(multi.Mismatches) > 1 {
+		fmt.Fprintf(&sb, "%d mismatches", len(multi.Mismatches))
+		for _, m := range multi.Mismatches {
+			sb.WriteString("\n  ")
+			sb.WriteString(m.Error())
+		}
+	} else {
+		sb.WriteString(err.Error())
+	}
+	if diff := pt.differ.render(expected, actual); diff != "" {
+		sb.WriteString("\n")
+		sb.WriteString(diff)
+	}
+	sb.WriteString(pt.faker.note())
+	return sb.String()
 }
 
 func (pt *T) Cleanup(t TestingT) {
@@ -110,21 +270,134 @@ func (pt *T) Cleanup(t TestingT) {
 	})
 }
 
+// Begin isolates t in a transaction when the driver implements
+// database.Transactional and returns the context bound to it, which the code
+// under test must use. Seed and Assert called with t use it automatically;
+// other tests sharing the helper keep their own context. The transaction
+// is rolled back when the test completes, leaving the database untouched.
+// Drivers without transactions are torn down instead, like Cleanup, and
+// Begin returns t.Context().
+func (pt *T) Begin(t TestingT) context.Context {
+	t.Helper()
+	tx, ok := pt.poutine.(database.Transactional)
+	if !ok {
+		pt.Cleanup(t)
+		return t.Context()
+	}
+	ctx, err := tx.Begin(t.Context())
+	if errors.Is(err, errors.ErrUnsupported) {
+		pt.Cleanup(t)
+		return t.Context()
+	}
+	if err != nil {
+		t.Fatalf("begin transaction: %v", err)
+		return t.Context()
+	}
+	pt.mu.Lock()
+	pt.txCtxs[t] = ctx
+	pt.mu.Unlock()
+	t.Cleanup(func() {
+		pt.mu.Lock()
+		delete(pt.txCtxs, t)
+		pt.mu.Unlock()
+		// the test context is done by now; only keep the transaction
+		if err := tx.Rollback(context.WithoutCancel(ctx)); err != nil {
+			t.Fatalf("rollback: %v", err)
+		}
+	})
+	return ctx
+}
+
+// context returns the transaction context set by Begin for t, or
+// t.Context().
+func (pt *T) context(t TestingT) context.Context {
+	pt.mu.Lock()
+	defer pt.mu.Unlock()
+	if ctx, ok := pt.txCtxs[t]; ok {
+		return ctx
+	}
+	return t.Context()
+}
+
 func (pt *T) LoadJSON(t TestingT, path string) jwalk.Document {
 	t.Helper()
 	doc, err := pt.loader.load(path)
 	if err != nil {
 		t.Fatalf("load json %s: %v", path, err)
 	}
+	if pt.update && len(doc) > 0 && strings.EqualFold(filepath.Ext(path), ".json") {
+		if info, err := os.Stat(path); err == nil && info.Mode().IsRegular() && !isComposed(path) {
+			pt.addSource(t, path, doc)
+		}
+	}
 	return doc
 }
 
+// LoadJSONWith loads path like LoadJSON after expanding it as a template with
+// vars: "${name}" expressions are replaced by variables and {"$repeat": n}
+// array elements generate n documents. Templated documents are not cached and
+// are never rewritten in update mode.
+func (pt *T) LoadJSONWith(t TestingT, path string, vars map[string]any) jwalk.Document {
+	t.Helper()
+	doc, err := pt.loader.loadTemplate(path, newTemplate(vars, pt.marshalers))
+	if err != nil {
+		t.Fatalf("load json %s: %v", path, err)
+	}
+	return doc
+}
+
+// WriteJSON writes doc, typically a captured snapshot, to path as fixture
+// JSON. Driver values are encoded as the directives the driver registers so
+// the file can be loaded again with LoadJSON.
+func (pt *T) WriteJSON(t TestingT, path string, doc jwalk.Document) {
+	t.Helper()
+	if err := writeJSON(path, doc, pt.marshalers); err != nil {
+		t.Fatalf("write json %s: %v", path, err)
+	}
+}
+
+// loadedSource is a document as LoadJSON read it from path.
+type loadedSource struct {
+	path string
+	doc  jwalk.Document
+}
+
+// addSource records that doc was loaded from path until t completes.
+func (pt *T) addSource(t TestingT, path string, doc jwalk.Document) {
+	src := &loadedSource{path: path, doc: copyDoc(doc)}
+	pt.mu.Lock()
+	pt.sources = append(pt.sources, src)
+	pt.mu.Unlock()
+	t.Cleanup(func() {
+		pt.mu.Lock()
+		defer pt.mu.Unlock()
+		pt.sources = slices.DeleteFunc(pt.sources, func(s *loadedSource) bool { return s == src })
+	})
+}
+
+// source returns the fixture file doc was loaded from when update mode is
+// enabled. Documents are matched by content, so a document changed after
+// loading is not rewritten.
+func (pt *T) source(doc jwalk.Document) (string, bool) {
+	if !pt.update || len(doc) == 0 {
+		return "", false
+	}
+	pt.mu.Lock()
+	defer pt.mu.Unlock()
+	for _, src := range slices.Backward(pt.sources) {
+		if reflect.DeepEqual(src.doc, doc) {
+			return src.path, true
+		}
+	}
+	return "", false
+}
+
 type Snapshot struct {
 	pt       *T
 	expected jwalk.Document
 }
 
-func (s *Snapshot) Assert(t TestingT) {
+func (s *Snapshot) Assert(t TestingT) map[string]any {
 	t.Helper()
-	s.pt.Assert(t, s.expected)
+	return s.pt.Assert(t, s.expected)
 }
@@ -2,14 +2,21 @@ package testine
 
 import (
 	"context"
+	"fmt"
 	"os"
 	"path/filepath"
+	"slices"
 	"testing"
 
 	"github.com/calumari/jwalk"
+	"github.com/go-json-experiment/json"
+	"github.com/go-json-experiment/json/jsontext"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
 	"github.com/stretchr/testify/require"
+
+	"github.com/calumari/poutine"
+	"github.com/calumari/poutine/database/memory"
 )
 
 type mockPoutine struct{ mock.Mock }
@@ -40,6 +47,13 @@ func (m *mockPoutine) RegisterTypes(reg *jwalk.Registry) error { // optional reg
 	return args.Error(0)
 }
 
+type encoderPoutine struct {
+	*mockPoutine
+	marshalers *json.Marshalers
+}
+
+func (p *encoderPoutine) Marshalers() *json.Marshalers { return p.marshalers }
+
 type mockTester struct{ mock.Mock }
 
 func (m *mockTester) Test(expected, actual any) error {
@@ -47,23 +61,56 @@ func (m *mockTester) Test(expected, actual any) error {
 	return args.Error(0)
 }
 
-type mockTestingT struct{ mock.Mock }
+type mockTestingT struct {
+	fatal    string
+	logs     []string
+	cleanups []func()
+}
 
 func (ft *mockTestingT) Context() context.Context {
 	return context.Background()
 }
 
 func (ft *mockTestingT) Cleanup(f func()) {
-	ft.Called(f)
-	f()
+	ft.cleanups = append(ft.cleanups, f)
+}
+
+// finish runs the registered cleanups last to first, as the testing package
+// does when a test completes.
+func (ft *mockTestingT) finish() {
+	for _, f := range slices.Backward(ft.cleanups) {
+		f()
+	}
+	ft.cleanups = nil
 }
 
 func (ft *mockTestingT) Fatalf(format string, args ...any) {
+	ft.fatal = fmt.Sprintf(format, args...)
 }
 
 func (ft *mockTestingT) Helper() {
 }
 
+func (ft *mockTestingT) Logf(format string, args ...any) {
+	ft.logs = append(ft.logs, fmt.Sprintf(format, args...))
+}
+
+// newMemoryT returns a helper over a new memory driver. Colors are off so
+// failures can be compared as text.
+func newMemoryT(t *testing.T, opts ...Option) (*T, *memory.Driver) {
+	t.Helper()
+	driver := memory.NewDriver()
+	pt, err := New(poutine.New(driver), append([]Option{WithColor(false)}, opts...)...)
+	require.NoError(t, err)
+	return pt, driver
+}
+
+// loadTemp loads content through pt from a temporary .json fixture.
+func loadTemp(t *testing.T, pt *T, content string) jwalk.Document {
+	t.Helper()
+	return pt.LoadJSON(t, writeTemp(t, "fixture_*.json", content))
+}
+
 func docKV(k string, v any) jwalk.Document { return jwalk.Document{{Key: k, Value: v}} }
 
 func TestNew(t *testing.T) {
@@ -87,6 +134,48 @@ func TestNew(t *testing.T) {
 		assert.Equal(t, r, pt.registry)
 	})
 
+	t.Run("shared registry success registers once", func(t *testing.T) {
+		mp := &mockPoutine{}
+		mp.On("RegisterTypes", mock.Anything).Return(nil).Maybe()
+		r, _ := jwalk.NewRegistry()
+		_, err := New(mp, WithRegistry(r))
+		require.NoError(t, err)
+		_, err = New(mp, WithRegistry(r))
+		require.NoError(t, err)
+	})
+
+	t.Run("shared registry success shares fake generator", func(t *testing.T) {
+		mp := &mockPoutine{}
+		mp.On("RegisterTypes", mock.Anything).Return(nil).Maybe()
+		r, _ := jwalk.NewRegistry()
+		a, err := New(mp, WithRegistry(r), WithFakeSeed(42))
+		require.NoError(t, err)
+		b, err := New(mp, WithRegistry(r), WithFakeSeed(42))
+		require.NoError(t, err)
+		assert.Same(t, a.faker, b.faker)
+	})
+
+	t.Run("shared registry with other fake seed returns error", func(t *testing.T) {
+		mp := &mockPoutine{}
+		mp.On("RegisterTypes", mock.Anything).Return(nil).Maybe()
+		r, _ := jwalk.NewRegistry()
+		_, err := New(mp, WithRegistry(r), WithFakeSeed(42))
+		require.NoError(t, err)
+		_, err = New(mp, WithRegistry(r), WithFakeSeed(7))
+		assert.ErrorContains(t, err, "already draws from seed 42")
+	})
+
+	t.Run("registry with other fake directive returns error", func(t *testing.T) {
+		mp := &mockPoutine{}
+		mp.On("RegisterTypes", mock.Anything).Return(nil).Maybe()
+		r, _ := jwalk.NewRegistry()
+		require.NoError(t, r.Register(jwalk.NewDirective("fake", func(*jsontext.Decoder) (string, error) {
+			return "", nil
+		})))
+		_, err := New(mp, WithRegistry(r))
+		assert.ErrorContains(t, err, `directive "fake" already registered`)
+	})
+
 	t.Run("custom tester success uses provided tester", func(t *testing.T) {
 		mp := &mockPoutine{}
 		mp.On("RegisterTypes", mock.Anything).Return(nil).Maybe()
@@ -170,11 +259,13 @@ func TestT_Assert(t *testing.T) {
 		mp.On("RegisterTypes", mock.Anything).Return(nil).Maybe()
 		mp.On("Snapshot", mock.Anything).Return(want, nil).Once()
 		mt := &mockTester{}
-		mt.On("Test", mock.Anything, mock.Anything).Return(assert.AnError).Once()
-		pt, err := New(mp, WithTester(mt))
+		// the diff renderer consults the tester again for each mismatched leaf
+		mt.On("Test", mock.Anything, mock.Anything).Return(assert.AnError)
+		pt, err := New(mp, WithTester(mt), WithColor(false))
 		require.NoError(t, err)
 		ft := &mockTestingT{}
 		pt.Assert(ft, docKV("a", 2))
+		assert.Contains(t, ft.fatal, "- a: 2\n+ a: 1\n")
 		mp.AssertExpectations(t)
 		mt.AssertExpectations(t)
 	})
@@ -192,6 +283,27 @@ func TestT_Assert(t *testing.T) {
 		pt.Assert(ft, want)
 		mp.AssertExpectations(t)
 	})
+
+	t.Run("assert default tester mismatch reports all mismatches with diff", func(t *testing.T) {
+		mp := &mockPoutine{}
+		mp.On("RegisterTypes", mock.Anything).Return(nil).Maybe()
+		actual := docKV("pets", jwalk.Array{
+			jwalk.Document{{Key: "name", Value: "Luna"}, {Key: "age", Value: 3}},
+			jwalk.Document{{Key: "name", Value: "Bella"}, {Key: "age", Value: 5}},
+		})
+		mp.On("Snapshot", mock.Anything).Return(actual, nil).Once()
+		pt, err := New(mp, WithColor(false))
+		require.NoError(t, err)
+		ft := &mockTestingT{}
+		pt.Assert(ft, docKV("pets", jwalk.Array{
+			jwalk.Document{{Key: "name", Value: "Luna"}, {Key: "age", Value: 4}},
+			jwalk.Document{{Key: "name", Value: "Max"}, {Key: "age", Value: 5}},
+		}))
+		assert.Contains(t, ft.fatal, "assert: 2 mismatches")
+		assert.Contains(t, ft.fatal, "- pets[0].age: 4\n+ pets[0].age: 3\n")
+		assert.Contains(t, ft.fatal, "- pets[1].name: \"Max\"\n+ pets[1].name: \"Bella\"\n")
+		mp.AssertExpectations(t)
+	})
 }
 
 func TestT_Cleanup(t *testing.T) {
@@ -202,8 +314,8 @@ func TestT_Cleanup(t *testing.T) {
 		pt, err := New(mp)
 		require.NoError(t, err)
 		ft := &mockTestingT{}
-		ft.On("Cleanup", mock.Anything).Once()
 		pt.Cleanup(ft)
+		ft.finish()
 		mp.AssertExpectations(t)
 	})
 
@@ -214,8 +326,8 @@ func TestT_Cleanup(t *testing.T) {
 		pt, err := New(mp)
 		require.NoError(t, err)
 		ft := &mockTestingT{}
-		ft.On("Cleanup", mock.Anything).Once()
 		pt.Cleanup(ft)
+		ft.finish()
 		mp.AssertExpectations(t)
 	})
 }
@@ -251,6 +363,63 @@ func TestT_LoadJSON(t *testing.T) {
 	})
 }
 
+func TestT_WriteJSON(t *testing.T) {
+	type color struct{ name string }
+
+	t.Run("write json succeeds encodes indented fixture", func(t *testing.T) {
+		mp := &mockPoutine{}
+		mp.On("RegisterTypes", mock.Anything).Return(nil).Maybe()
+		pt, err := New(mp)
+		require.NoError(t, err)
+		path := filepath.Join(t.TempDir(), "out.json")
+		pt.WriteJSON(t, path, docKV("pets", jwalk.Array{
+			jwalk.Document{{Key: "name", Value: "Luna"}, {Key: "tags", Value: jwalk.Array{"cat"}}},
+		}))
+		got, err := os.ReadFile(path)
+		require.NoError(t, err)
+		want := `{
+  "pets": [
+    {
+      "name": "Luna",
+      "tags": [
+        "cat"
+      ]
+    }
+  ]
+}
+`
+		assert.Equal(t, want, string(got))
+		assert.Equal(t, docKV("pets", jwalk.Array{
+			jwalk.Document{{Key: "name", Value: "Luna"}, {Key: "tags", Value: jwalk.Array{"cat"}}},
+		}), pt.LoadJSON(t, path))
+	})
+
+	t.Run("write json encoder poutine succeeds uses driver marshalers", func(t *testing.T) {
+		mp := &mockPoutine{}
+		mp.On("RegisterTypes", mock.Anything).Return(nil).Maybe()
+		ep := &encoderPoutine{mockPoutine: mp, marshalers: json.MarshalToFunc(func(enc *jsontext.Encoder, c color) error {
+			return enc.WriteValue(jsontext.Value(`{"$color":"` + c.name + `"}`))
+		})}
+		pt, err := New(ep)
+		require.NoError(t, err)
+		path := filepath.Join(t.TempDir(), "out.json")
+		pt.WriteJSON(t, path, docKV("paint", jwalk.Array{docKV("color", color{name: "red"})}))
+		got, err := os.ReadFile(path)
+		require.NoError(t, err)
+		assert.Contains(t, string(got), `"$color": "red"`)
+	})
+
+	t.Run("write json missing directory returns fatal", func(t *testing.T) {
+		mp := &mockPoutine{}
+		mp.On("RegisterTypes", mock.Anything).Return(nil).Maybe()
+		pt, err := New(mp)
+		require.NoError(t, err)
+		ft := &mockTestingT{}
+		pt.WriteJSON(ft, filepath.Join(t.TempDir(), "missing", "out.json"), docKV("a", jwalk.Array{}))
+		assert.Contains(t, ft.fatal, "write json")
+	})
+}
+
 func TestSnapshot_Assert(t *testing.T) {
 	t.Run("snapshot assert success matches expected", func(t *testing.T) {
 		want := docKV("a", 1)
@@ -265,3 +434,36 @@ func TestSnapshot_Assert(t *testing.T) {
 		mp.AssertExpectations(t)
 	})
 }
+
+func TestT_MemoryDriver(t *testing.T) {
+	t.Run("seed mutate and assert round trip succeeds", func(t *testing.T) {
+		pt, driver := newMemoryT(t)
+		snap := pt.Seed(t, jwalk.Document{
+			{Key: "users", Value: jwalk.Array{jwalk.Document{{Key: "name", Value: "Alice"}}}},
+		})
+		snap.Assert(t)
+
+		driver.Insert("users", jwalk.Document{{Key: "name", Value: "Bob"}})
+		pt.Assert(t, jwalk.Document{
+			{Key: "users", Value: jwalk.Array{
+				jwalk.Document{{Key: "name", Value: "Alice"}},
+				jwalk.Document{{Key: "name", Value: "Bob"}},
+			}},
+		})
+	})
+
+	t.Run("assert with matcher directives succeeds", func(t *testing.T) {
+		pt, driver := newMemoryT(t)
+		driver.Insert("users", jwalk.Document{
+			{Key: "email", Value: "alice@example.com"},
+			{Key: "age", Value: 30.0},
+			{Key: "roles", Value: jwalk.Array{"user", "admin"}},
+		})
+		path := writeTemp(t, "matchers_*.json", `{"users": [{
+			"email": {"$regex": "@example\\.com$"},
+			"age": {"$anyOf": [{"$gte": 18}, {"$type": "null"}]},
+			"roles": {"$contains": "admin"}
+		}]}`)
+		pt.Assert(t, pt.LoadJSON(t, path))
+	})
+}
@@ -0,0 +1,166 @@
+package testine
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+
+	"github.com/calumari/jwalk"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/calumari/poutine"
+	"github.com/calumari/poutine/database/memory"
+)
+
+type txKey struct{}
+
+// txPoutine records the contexts it is called with and rolls back by
+// restoring the snapshot taken at Begin.
+type txPoutine struct {
+	*poutine.Poutine
+	driver   *memory.Driver
+	begun    jwalk.Document
+	ctxs     []context.Context
+	rollback context.Context
+	err      error
+}
+
+func (p *txPoutine) Seed(ctx context.Context, root jwalk.Document) (jwalk.Document, error) {
+	p.ctxs = append(p.ctxs, ctx)
+	return p.Poutine.Seed(ctx, root)
+}
+
+func (p *txPoutine) Snapshot(ctx context.Context) (jwalk.Document, error) {
+	p.ctxs = append(p.ctxs, ctx)
+	return p.Poutine.Snapshot(ctx)
+}
+
+func (p *txPoutine) Begin(ctx context.Context) (context.Context, error) {
+	if p.err != nil {
+		return nil, p.err
+	}
+	p.begun, _ = p.driver.Snapshot(ctx)
+	return context.WithValue(ctx, txKey{}, true), nil
+}
+
+func (p *txPoutine) Rollback(ctx context.Context) error {
+	p.rollback = ctx
+	if err := p.driver.Teardown(ctx); err != nil {
+		return err
+	}
+	_, err := p.driver.Seed(ctx, p.begun)
+	return err
+}
+
+// countingTxPoutine begins transactions numbered from 1 and is safe for
+// concurrent use.
+type countingTxPoutine struct {
+	*poutine.Poutine
+	mu sync.Mutex
+	n  int
+}
+
+func (p *countingTxPoutine) Begin(ctx context.Context) (context.Context, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.n++
+	return context.WithValue(ctx, txKey{}, p.n), nil
+}
+
+func (p *countingTxPoutine) Rollback(context.Context) error {
+	return nil
+}
+
+func TestT_Begin(t *testing.T) {
+	seed := jwalk.Document{{Key: "pets", Value: jwalk.Array{jwalk.Document{{Key: "name", Value: "Luna"}}}}}
+
+	t.Run("transactional driver rolls back at cleanup succeeds", func(t *testing.T) {
+		driver := memory.NewDriver()
+		_, err := driver.Seed(t.Context(), jwalk.Document{{Key: "owners", Value: jwalk.Array{jwalk.Document{{Key: "name", Value: "Ann"}}}}})
+		require.NoError(t, err)
+		p := &txPoutine{Poutine: poutine.New(driver), driver: driver}
+		pt, err := New(p)
+		require.NoError(t, err)
+
+		t.Run("test", func(t *testing.T) {
+			ctx := pt.Begin(t)
+			assert.Equal(t, true, ctx.Value(txKey{}))
+			pt.Seed(t, seed)
+			pt.Assert(t, seed)
+			for _, c := range p.ctxs {
+				assert.Equal(t, true, c.Value(txKey{}))
+			}
+		})
+
+		require.NotNil(t, p.rollback)
+		assert.Equal(t, true, p.rollback.Value(txKey{}))
+		assert.NoError(t, p.rollback.Err(), "rollback context must not be canceled")
+		got, err := driver.Snapshot(t.Context())
+		require.NoError(t, err)
+		assert.Equal(t, jwalk.Document{{Key: "owners", Value: jwalk.Array{jwalk.Document{{Key: "name", Value: "Ann"}}}}}, got)
+		assert.Empty(t, pt.txCtxs)
+	})
+
+	t.Run("unsupported driver tears down at cleanup succeeds", func(t *testing.T) {
+		pt, driver := newMemoryT(t)
+
+		t.Run("test", func(t *testing.T) {
+			ctx := pt.Begin(t)
+			assert.Equal(t, t.Context(), ctx)
+			pt.Seed(t, seed)
+		})
+
+		got, err := driver.Snapshot(t.Context())
+		require.NoError(t, err)
+		assert.Empty(t, got)
+	})
+
+	t.Run("begin error returns fatal", func(t *testing.T) {
+		driver := memory.NewDriver()
+		pt, err := New(&txPoutine{Poutine: poutine.New(driver), driver: driver, err: errors.New("no replica set")})
+		require.NoError(t, err)
+		ft := &mockTestingT{}
+		pt.Begin(ft)
+		assert.Equal(t, "begin transaction: no replica set", ft.fatal)
+	})
+
+	t.Run("concurrent tests keep their own transaction succeeds", func(t *testing.T) {
+		pt, err := New(&countingTxPoutine{Poutine: poutine.New(memory.NewDriver())})
+		require.NoError(t, err)
+		var begun sync.WaitGroup
+		begun.Add(2)
+		firstDone := make(chan struct{})
+
+		// t.Run may be called from several goroutines; the subtests then run
+		// concurrently whatever -parallel is
+		var wg sync.WaitGroup
+		wg.Add(2)
+		go func() {
+			defer wg.Done()
+			t.Run("first", func(t *testing.T) {
+				t.Cleanup(func() { close(firstDone) }) // runs after the rollback
+				ctx := pt.Begin(t)
+				begun.Done()
+				begun.Wait()
+				assert.Equal(t, ctx, pt.context(t))
+				pt.Seed(t, seed)
+			})
+		}()
+		go func() {
+			defer wg.Done()
+			t.Run("second", func(t *testing.T) {
+				ctx := pt.Begin(t)
+				begun.Done()
+				begun.Wait()
+				assert.Equal(t, ctx, pt.context(t))
+				<-firstDone
+				assert.Equal(t, ctx, pt.context(t), "cleanup of another test must not end this transaction")
+				pt.Seed(t, seed)
+			})
+		}()
+		wg.Wait()
+		assert.Empty(t, pt.txCtxs)
+	})
+}